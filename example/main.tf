@@ -14,40 +14,43 @@ provider "libvirtapi" {
 }
 
 resource "libvirtapi_loadbalancer" "lbApi" {
-  name = "db"
-	namespace= "ee"
-  nodes = [{
-    name = "12"
-    ip = "3.3.2.121"
-  },{
-    name = "13"
-    ip = "3.3.2.13"
+  name      = "db"
+  namespace = "ee"
+  nodes = {
+    "12" = {
+      ip = "3.3.2.121"
+    }
+    "13" = {
+      ip = "3.3.2.13"
+    }
   }
-  ]
-  ports = [{
-    name = "test"
-    protocol = "tcp"
-    port = "801"
-    nodeport = "1234"
-  },
-  {
-    name = "test11"
-    protocol = "tcp"
-    port = "81"
-    nodeport = "123"
+  ports = {
+    test = {
+      protocol = "tcp"
+      port     = "801"
+      nodeport = "1234"
+    }
+    test11 = {
+      protocol = "tcp"
+      port     = "81"
+      nodeport = "123"
+    }
   }
-  ]
 }
 
 
 # data "libvirtapi_network" "static" {
-#   id = 2
 #   name = "ha"
-#   status = 0
 # }
 
 # resource "libvirtapi_network" "internal01" {
 #   name = "ha"
+#   cidr = "192.168.100.0/24"
+#   dhcp = {
+#     start = "192.168.100.10"
+#     end   = "192.168.100.200"
+#   }
+#   autostart = true
 # }
 
 # resource "libvirtapi_network" "internal11" {
@@ -55,6 +58,18 @@ resource "libvirtapi_loadbalancer" "lbApi" {
 # }
 
 # resource "libvirtapi_vm" "test" {
-#   name = "db"
-#   network = [data.libvirtapi_network.static.id,resource.libvirtapi_network.internal01.id]
+#   name   = "db"
+#   vcpu   = 2
+#   memory = 2048
+#   disks = [{
+#     size = 20
+#   }]
+#   networks = [data.libvirtapi_network.static.id, resource.libvirtapi_network.internal01.id]
+# }
+
+# resource "libvirtapi_network_dhcp_host" "web" {
+#   network_id = resource.libvirtapi_network.internal01.id
+#   mac        = "52:54:00:12:34:56"
+#   ip         = "192.168.100.5"
+#   hostname   = "web-1"
 # }
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+)
+
+// apiError is returned by apiRequest when the server answers with a non 2xx status.
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("libvirtApi returned HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// isNotFound reports whether err is an apiError for a missing object.
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// apiRequest sends a JSON request to the libvirtApi server through the transport and
+// token of an already configured client. It covers the endpoints libvirtApiClient
+// does not wrap yet. in and out may be nil.
+func apiRequest(ctx context.Context, c *libvirtApiClient.Client, method string, uri string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.HostURL+uri, body)
+	if err != nil {
+		return err
+	}
+	if c.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return &apiError{StatusCode: response.StatusCode, Body: string(data)}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,282 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                   = &certificateResource{}
+	_ resource.ResourceWithConfigure      = &certificateResource{}
+	_ resource.ResourceWithValidateConfig = &certificateResource{}
+)
+
+// NewCertificateResource is a helper function to simplify the provider implementation.
+func NewCertificateResource() resource.Resource {
+	return &certificateResource{}
+}
+
+// certificateResource is the resource implementation.
+type certificateResource struct {
+	client *libvirtApiClient.Client
+}
+
+type certificateResourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	Name        string       `tfsdk:"name"`
+	Certificate string       `tfsdk:"certificate"`
+	PrivateKey  string       `tfsdk:"private_key"`
+	Chain       types.String `tfsdk:"certificate_chain"`
+	Expiry      types.String `tfsdk:"expiry"`
+	Fingerprint types.String `tfsdk:"fingerprint"`
+}
+
+// certificatePayload is the body of the /api/certificate endpoints, the
+// server never sends the private key back.
+type certificatePayload struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Certificate string `json:"certificate,omitempty"`
+	PrivateKey  string `json:"private_key,omitempty"`
+	Chain       string `json:"certificate_chain,omitempty"`
+}
+
+func (r *certificateResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*libvirtapiResourceData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *libvirtapiResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = data.client
+}
+
+// Metadata returns the resource type name.
+func (r *certificateResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_certificate"
+}
+
+// Schema defines the schema for the resource.
+func (r *certificateResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	replace := []planmodifier.String{
+		stringplanmodifier.RequiresReplace(),
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Certificate for the tls listeners of libvirtapi_loadbalancer. Any change uploads a new one.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: replace,
+			},
+			"certificate": schema.StringAttribute{
+				Description:   "PEM encoded certificate.",
+				Required:      true,
+				PlanModifiers: replace,
+			},
+			"private_key": schema.StringAttribute{
+				Description:   "PEM encoded private key of certificate.",
+				Required:      true,
+				Sensitive:     true,
+				PlanModifiers: replace,
+			},
+			"certificate_chain": schema.StringAttribute{
+				Description:   "PEM encoded intermediate certificates.",
+				Optional:      true,
+				PlanModifiers: replace,
+			},
+			"expiry": schema.StringAttribute{
+				Description: "End of the validity of certificate, in RFC 3339 format.",
+				Computed:    true,
+
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"fingerprint": schema.StringAttribute{
+				Description: "SHA-256 fingerprint of certificate, hex encoded.",
+				Computed:    true,
+
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+// ValidateConfig checks that the key belongs to the certificate before
+// anything is uploaded.
+func (r *certificateResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var certificate, privateKey, chain types.String
+
+	diags := req.Config.GetAttribute(ctx, path.Root("certificate"), &certificate)
+	resp.Diagnostics.Append(diags...)
+	diags = req.Config.GetAttribute(ctx, path.Root("private_key"), &privateKey)
+	resp.Diagnostics.Append(diags...)
+	diags = req.Config.GetAttribute(ctx, path.Root("certificate_chain"), &chain)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if certificate.IsUnknown() || certificate.IsNull() || privateKey.IsUnknown() || privateKey.IsNull() || chain.IsUnknown() {
+		return
+	}
+
+	_, err := parseCertificate(certificate.ValueString(), privateKey.ValueString(), chain.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("certificate"), "Invalid Certificate", err.Error())
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *certificateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan certificateResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	leaf, err := parseCertificate(plan.Certificate, plan.PrivateKey, plan.Chain.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("certificate"), "Invalid Certificate", err.Error())
+		return
+	}
+
+	var certificate certificatePayload
+	err = apiRequest(ctx, r.client, http.MethodPost, "/api/certificate", certificatePayload{
+		Name:        plan.Name,
+		Certificate: plan.Certificate,
+		PrivateKey:  plan.PrivateKey,
+		Chain:       plan.Chain.ValueString(),
+	}, &certificate)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating certificate",
+			"Could not create certificate, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	fingerprint := sha256.Sum256(leaf.Raw)
+	plan.ID = types.StringValue(certificate.ID)
+	plan.Expiry = types.StringValue(leaf.NotAfter.UTC().Format(time.RFC3339))
+	plan.Fingerprint = types.StringValue(hex.EncodeToString(fingerprint[:]))
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *certificateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state certificateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var certificate certificatePayload
+	err := apiRequest(ctx, r.client, http.MethodGet, "/api/certificate/"+url.PathEscape(state.ID.ValueString()), nil, &certificate)
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading certificate",
+			"Could not read certificate "+state.ID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	// The PEM blocks stay as configured, the server may reformat them.
+	state.Name = certificate.Name
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update is never called with changes, every attribute requires a replacement.
+func (r *certificateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan certificateResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *certificateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state certificateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := apiRequest(ctx, r.client, http.MethodDelete, "/api/certificate/"+url.PathEscape(state.ID.ValueString()), nil, nil)
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError(
+			"Error Deleting certificate",
+			"Could not delete certificate, unexpected error: "+err.Error(),
+		)
+		return
+	}
+}
+
+// parseCertificate checks that privateKey matches certificate and returns
+// the parsed certificate.
+func parseCertificate(certificate string, privateKey string, chain string) (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair([]byte(certificate+"\n"+chain), []byte(privateKey))
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(pair.Certificate[0])
+}
@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentialRefreshWindow is how long before expiration credentials are renewed.
+const credentialRefreshWindow = time.Minute
+
+// processCredentials is what a credential_process command prints on stdout, e.g.
+//
+//	{"token": "eyJhbGciOi...", "expiration": "2024-08-01T20:10:54Z"}
+//
+// A missing expiration means the token never expires.
+type processCredentials struct {
+	Token      string    `json:"token"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// credentialProcess runs a local command for short lived tokens and caches
+// its output until shortly before it expires.
+type credentialProcess struct {
+	command []string
+
+	mu      sync.Mutex
+	current *processCredentials
+}
+
+func newCredentialProcess(command string) (*credentialProcess, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("credential_process is empty")
+	}
+	return &credentialProcess{command: args}, nil
+}
+
+// Token returns a valid token, running the command again when needed.
+func (p *credentialProcess) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current != nil && (p.current.Expiration.IsZero() || time.Until(p.current.Expiration) > credentialRefreshWindow) {
+		return p.current.Token, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running credential_process: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var credentials processCredentials
+	if err := json.Unmarshal(stdout.Bytes(), &credentials); err != nil {
+		return "", fmt.Errorf("parsing credential_process output: %w", err)
+	}
+	if credentials.Token == "" {
+		return "", fmt.Errorf("credential_process returned no token")
+	}
+
+	p.current = &credentials
+	return credentials.Token, nil
+}
+
+// credentialTransport sets the bearer token of a credentialProcess on every request.
+type credentialTransport struct {
+	process *credentialProcess
+	next    http.RoundTripper
+}
+
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.process.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	failoverFirstHealthy = "first_healthy"
+	failoverRoundRobin   = "round_robin"
+)
+
+// failoverTransport spreads requests over several libvirtApi hosts. Requests
+// are built against the first endpoint by libvirtApiClient and rewritten to
+// the endpoint picked by the policy. A connection failure moves on to the next
+// endpoint, for non idempotent requests only when the connection could not be
+// opened, the first endpoint may have processed it otherwise.
+type failoverTransport struct {
+	next      http.RoundTripper
+	endpoints []*url.URL
+	policy    string
+
+	// logCtx is the context of Configure, like retryTransport.logCtx. The
+	// endpoint switches are logged there.
+	logCtx context.Context
+
+	mu      sync.Mutex
+	healthy int
+	turn    int
+}
+
+func newFailoverTransport(ctx context.Context, next http.RoundTripper, endpoints []string, policy string) (*failoverTransport, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("at least one endpoint is required")
+	}
+	if policy != failoverFirstHealthy && policy != failoverRoundRobin {
+		return nil, fmt.Errorf("failover_policy must be %q or %q, got %q", failoverFirstHealthy, failoverRoundRobin, policy)
+	}
+
+	t := &failoverTransport{next: next, policy: policy, logCtx: ctx}
+	for _, endpoint := range endpoints {
+		parsed, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %q: %w", endpoint, err)
+		}
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("endpoint %q must be an absolute URL", endpoint)
+		}
+		t.endpoints = append(t.endpoints, parsed)
+	}
+	return t, nil
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.start(req)
+
+	var lastErr error
+	for i := range t.endpoints {
+		index := (start + i) % len(t.endpoints)
+		endpoint := t.endpoints[index]
+
+		attempt, err := t.rewrite(req, endpoint, i > 0)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := t.next.RoundTrip(attempt)
+		if err == nil {
+			tflog.Debug(t.logCtx, "libvirtapi request served", map[string]interface{}{
+				"method":   req.Method,
+				"path":     attempt.URL.Path,
+				"endpoint": endpoint.String(),
+				"status":   response.StatusCode,
+			})
+			return response, nil
+		}
+
+		lastErr = err
+		t.markUnhealthy(index)
+		tflog.Warn(t.logCtx, "libvirtapi endpoint failed", map[string]interface{}{
+			"method":   req.Method,
+			"endpoint": endpoint.String(),
+			"error":    err.Error(),
+		})
+
+		if req.Context().Err() != nil || (req.Body != nil && req.GetBody == nil) {
+			break
+		}
+		if !idempotent(req.Method) && !isDialError(err) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// start returns the index of the endpoint tried first.
+func (t *failoverTransport) start(req *http.Request) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.policy == failoverRoundRobin && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		t.turn = (t.turn + 1) % len(t.endpoints)
+		return t.turn
+	}
+	return t.healthy
+}
+
+// markUnhealthy moves the preferred endpoint past a failed one.
+func (t *failoverTransport) markUnhealthy(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.healthy == index {
+		t.healthy = (index + 1) % len(t.endpoints)
+	}
+}
+
+// isDialError reports whether err happened before the request was sent.
+func isDialError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// rewrite points req at endpoint, replaying the body when it was already sent.
+func (t *failoverTransport) rewrite(req *http.Request, endpoint *url.URL, replay bool) (*http.Request, error) {
+	attempt := req.Clone(req.Context())
+	if replay && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attempt.Body = body
+	}
+
+	base := t.endpoints[0]
+	attempt.URL.Scheme = endpoint.Scheme
+	attempt.URL.Host = endpoint.Host
+	attempt.URL.Path = endpoint.Path + strings.TrimPrefix(req.URL.Path, base.Path)
+	attempt.URL.RawPath = ""
+	attempt.Host = ""
+	return attempt, nil
+}
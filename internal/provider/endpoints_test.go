@@ -0,0 +1,240 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testEndpoint is a libvirtApi host that records what it was sent.
+type testEndpoint struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func newTestEndpoint(t *testing.T, name string) *testEndpoint {
+	e := &testEndpoint{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		e.mu.Lock()
+		e.requests = append(e.requests, r.Method+" "+r.URL.Path+" "+string(body))
+		e.mu.Unlock()
+		_, _ = io.WriteString(w, name)
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+func (e *testEndpoint) received() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.requests...)
+}
+
+// newDeadEndpoint returns the URL of a host that refuses connections.
+func newDeadEndpoint() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+// newDroppingEndpoint returns a host that reads the request and closes the
+// connection without an answer.
+func newDroppingEndpoint(t *testing.T, requests *int32) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func sendThrough(t *testing.T, transport http.RoundTripper, method string, url string, body string) (string, error) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := (&http.Client{Transport: transport}).Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	served, _ := io.ReadAll(response.Body)
+	return string(served), nil
+}
+
+func TestNewFailoverTransport(t *testing.T) {
+	cases := []struct {
+		name      string
+		endpoints []string
+		policy    string
+		wantErr   bool
+	}{
+		{"valid", []string{"https://a:8050", "https://b:8050/"}, failoverFirstHealthy, false},
+		{"round robin", []string{"https://a:8050"}, failoverRoundRobin, false},
+		{"none", nil, failoverFirstHealthy, true},
+		{"relative", []string{"a:8050/api"}, failoverFirstHealthy, true},
+		{"unknown policy", []string{"https://a:8050"}, "random", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := newFailoverTransport(context.Background(), http.DefaultTransport, c.endpoints, c.policy)
+			if (err != nil) != c.wantErr {
+				t.Errorf("got error %v, want error %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestFailoverTransportFirstHealthy(t *testing.T) {
+	b := newTestEndpoint(t, "b")
+	c := newTestEndpoint(t, "c")
+	dead := newDeadEndpoint()
+
+	transport, err := newFailoverTransport(context.Background(), http.DefaultTransport, []string{dead, b.URL, c.URL}, failoverFirstHealthy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The dead endpoint is skipped and b is then preferred until it fails.
+	for i := 0; i < 3; i++ {
+		served, err := sendThrough(t, transport, http.MethodGet, dead+"/api/lb", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if served != "b" {
+			t.Errorf("request %d: served by %s, want b", i, served)
+		}
+	}
+
+	b.Close()
+	served, err := sendThrough(t, transport, http.MethodGet, dead+"/api/lb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if served != "c" {
+		t.Errorf("served by %s after b failed, want c", served)
+	}
+}
+
+func TestFailoverTransportRoundRobin(t *testing.T) {
+	a := newTestEndpoint(t, "a")
+	b := newTestEndpoint(t, "b")
+
+	transport, err := newFailoverTransport(context.Background(), http.DefaultTransport, []string{a.URL, b.URL}, failoverRoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var served []string
+	for i := 0; i < 4; i++ {
+		name, err := sendThrough(t, transport, http.MethodGet, a.URL+"/api/lb", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		served = append(served, name)
+	}
+	if strings.Join(served, ",") != "b,a,b,a" {
+		t.Errorf("GET served by %v, want them spread", served)
+	}
+
+	// Writes stay on the preferred endpoint.
+	for i := 0; i < 2; i++ {
+		name, err := sendThrough(t, transport, http.MethodPut, a.URL+"/api/lb", "{}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "a" {
+			t.Errorf("PUT served by %s, want a", name)
+		}
+	}
+}
+
+func TestFailoverTransportRewrite(t *testing.T) {
+	b := newTestEndpoint(t, "b")
+	dead := newDeadEndpoint()
+
+	transport, err := newFailoverTransport(context.Background(), http.DefaultTransport, []string{dead + "/v1", b.URL + "/proxy/"}, failoverFirstHealthy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sendThrough(t, transport, http.MethodPut, dead+"/v1/api/lb", `{"name":"db"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `PUT /proxy/api/lb {"name":"db"}`
+	if requests := b.received(); len(requests) != 1 || requests[0] != want {
+		t.Errorf("got %q, want %q", requests, want)
+	}
+}
+
+func TestFailoverTransportReplay(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		dropped    bool
+		wantServed bool
+	}{
+		{"get after dropped connection", http.MethodGet, true, true},
+		{"put after dropped connection", http.MethodPut, true, true},
+		{"delete after dropped connection", http.MethodDelete, true, true},
+		{"post after refused connection", http.MethodPost, false, true},
+		{"post after dropped connection", http.MethodPost, true, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := newTestEndpoint(t, "b")
+			var dropped int32
+			first := newDeadEndpoint()
+			if c.dropped {
+				first = newDroppingEndpoint(t, &dropped)
+			}
+
+			transport, err := newFailoverTransport(context.Background(), http.DefaultTransport, []string{first, b.URL}, failoverFirstHealthy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := `{"name":"db"}`
+			_, err = sendThrough(t, transport, c.method, first+"/api/lb", body)
+
+			if c.wantServed {
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := c.method + " /api/lb " + body
+				if requests := b.received(); len(requests) != 1 || requests[0] != want {
+					t.Errorf("second endpoint got %q, want %q", requests, want)
+				}
+				return
+			}
+
+			// The first endpoint may have created the object already.
+			if err == nil {
+				t.Error("expected the error of the first endpoint")
+			}
+			if got := atomic.LoadInt32(&dropped); got != 1 || len(b.received()) != 0 {
+				t.Errorf("sent %d times to the first endpoint and %d to the second, want 1 and 0", got, len(b.received()))
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+)
+
+// The functions below mirror the load balancer calls of libvirtApiClient, on
+// the same endpoints, with the settings of loadbalancerPayload.
+
+func getLoadBalancer(ctx context.Context, client *libvirtApiClient.Client, namespace string, name string) (*loadbalancerPayload, bool, error) {
+	var lb loadbalancerPayload
+	err := apiRequest(ctx, client, http.MethodGet, fmt.Sprintf("/api/lb/%s/%s", url.PathEscape(namespace), url.PathEscape(name)), nil, &lb)
+	if isNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	// The server answers an empty object for unknown load balancers.
+	if lb.Ip == "" {
+		return nil, false, nil
+	}
+	return &lb, true, nil
+}
+
+func listLoadBalancers(ctx context.Context, client *libvirtApiClient.Client) ([]loadbalancerPayload, error) {
+	var lbs []loadbalancerPayload
+	err := apiRequest(ctx, client, http.MethodGet, "/api/lb", nil, &lbs)
+	if err != nil {
+		return nil, err
+	}
+	return lbs, nil
+}
+
+func createLoadBalancer(ctx context.Context, client *libvirtApiClient.Client, bind_payload loadbalancerPayload) (*loadbalancerPayload, error) {
+	var lb loadbalancerPayload
+	err := apiRequest(ctx, client, http.MethodPost, "/api/lb", bind_payload, &lb)
+	if err != nil {
+		return nil, err
+	}
+	return &lb, nil
+}
+
+// updateLoadBalancerSettings has no counterpart in libvirtApiClient, unlike a
+// PUT on /api/lb it leaves the nodes and ports alone.
+func updateLoadBalancerSettings(ctx context.Context, client *libvirtApiClient.Client, namespace string, name string, settings loadbalancerSettingsPayload) error {
+	return apiRequest(ctx, client, http.MethodPut, fmt.Sprintf("/api/lb/%s/%s/settings", url.PathEscape(namespace), url.PathEscape(name)), settings, nil)
+}
+
+func deleteLoadBalancer(ctx context.Context, client *libvirtApiClient.Client, bind_payload loadbalancerPayload) error {
+	return apiRequest(ctx, client, http.MethodDelete, "/api/lb", bind_payload, nil)
+}
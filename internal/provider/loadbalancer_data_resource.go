@@ -35,49 +35,64 @@ func (r *loadbalancerDataSource) Metadata(_ context.Context, req datasource.Meta
 	resp.TypeName = req.ProviderTypeName + "_loadbalancer"
 }
 func (d *loadbalancerDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attributes := loadbalancerComputedAttributes()
+	attributes["name"] = schema.StringAttribute{
+		Required: true,
+	}
+	attributes["namespace"] = schema.StringAttribute{
+		Required: true,
+	}
+
 	resp.Schema = schema.Schema{
-		Attributes: map[string]schema.Attribute{
-			"name": schema.StringAttribute{
-				Required: true,
-			},
-			"namespace": schema.StringAttribute{
-				Required: true,
-			},
-			"ports": schema.ListNestedAttribute{
-				Computed: true,
-				NestedObject: schema.NestedAttributeObject{
-					Attributes: map[string]schema.Attribute{
-						"name": schema.StringAttribute{
-							Computed: true,
-						},
-						"protocol": schema.StringAttribute{
-							Computed: true,
-						},
-						"port": schema.Int64Attribute{
-							Computed: true,
-						},
-						"nodeport": schema.Int64Attribute{
-							Computed: true,
-						},
+		Attributes: attributes,
+	}
+}
+
+// loadbalancerComputedAttributes returns the attributes filled from the server,
+// they are shared with libvirtapi_loadbalancers.
+func loadbalancerComputedAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			Computed: true,
+		},
+		"ip": schema.StringAttribute{
+			Computed: true,
+		},
+		"ports": schema.ListNestedAttribute{
+			Computed: true,
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"name": schema.StringAttribute{
+						Computed: true,
+					},
+					"protocol": schema.StringAttribute{
+						Computed: true,
+					},
+					"port": schema.Int64Attribute{
+						Computed: true,
+					},
+					"nodeport": schema.Int64Attribute{
+						Computed: true,
 					},
 				},
 			},
-			"nodes": schema.ListNestedAttribute{
-				Computed: true,
-				NestedObject: schema.NestedAttributeObject{
-					Attributes: map[string]schema.Attribute{
-						"name": schema.StringAttribute{
-							Computed: true,
-						},
-						"ip": schema.StringAttribute{
-							Required: true,
-						},
+		},
+		"nodes": schema.ListNestedAttribute{
+			Computed: true,
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"name": schema.StringAttribute{
+						Computed: true,
+					},
+					"ip": schema.StringAttribute{
+						Computed: true,
 					},
 				},
 			},
 		},
 	}
 }
+
 func NewLoadbalancerDataSource() datasource.DataSource {
 	return &loadbalancerDataSource{}
 }
@@ -85,46 +100,30 @@ func NewLoadbalancerDataSource() datasource.DataSource {
 func (d *loadbalancerDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var data loadbalancerDataSourceModel
 
-	paylaod := libvirtApiClient.LoadBalancer{
-		Name:      data.name,
-		Namespace: data.namespace,
-	}
-
 	diags := req.Config.Get(ctx, &data)
 
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	loadbalancer, exist, err := d.client.GetLoadBalancer(paylaod)
-	if err != nil || !exist {
+
+	loadbalancer, exist, err := getLoadBalancer(ctx, d.client, data.Namespace, data.Name)
+	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Read loadbalancer",
 			err.Error(),
 		)
 		return
 	}
-	var ports []Port
-	for _, port := range loadbalancer.Ports {
-		port_object := Port{
-			Name:     port.Name,
-			Protocol: port.Protocol,
-			Port:     port.Port,
-			NodePort: port.NodePort,
-		}
-		ports = append(ports, port_object)
-	}
-	var nodes []Node
-	for _, node := range loadbalancer.Nodes {
-		node_object := Node{
-			Name: node.Name,
-			IP:   node.IP,
-		}
-		nodes = append(nodes, node_object)
+	if !exist {
+		resp.Diagnostics.AddError(
+			"Load Balancer Not Found",
+			"LoadBalancer "+loadbalancerID(data.Namespace, data.Name)+" does not exist",
+		)
+		return
 	}
 
-	data.nodes = nodes
-	data.ports = ports
+	data.refresh(loadbalancer)
 
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
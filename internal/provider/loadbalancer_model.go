@@ -1,12 +1,25 @@
 package provider
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
 
+const (
+	defaultAlgorithm       = "round_robin"
+	defaultSessionAffinity = "none"
+	defaultNodeWeight      = 1
+	defaultNodeState       = "active"
+	defaultDrainTimeout    = 30
+)
+
 type loadbalancerResource struct {
-	client *libvirtApiClient.Client
+	client    *libvirtApiClient.Client
+	nodePorts *nodePortRange
 }
 
 type loadbalancerDataSource struct {
@@ -26,16 +39,398 @@ type Node struct {
 }
 
 type loadbalancerDataSourceModel struct {
-	name      string
-	namespace string
-	ports     []Port
-	nodes     []Node
+	ID        basetypes.StringValue `tfsdk:"id"`
+	Name      string                `tfsdk:"name"`
+	Namespace string                `tfsdk:"namespace"`
+	Ip        basetypes.StringValue `tfsdk:"ip"`
+	Ports     []Port                `tfsdk:"ports"`
+	Nodes     []Node                `tfsdk:"nodes"`
+}
+
+type loadbalancersDataSource struct {
+	client *libvirtApiClient.Client
+}
+
+type loadbalancersDataSourceModel struct {
+	Namespace     basetypes.StringValue         `tfsdk:"namespace"`
+	NamePrefix    basetypes.StringValue         `tfsdk:"name_prefix"`
+	Protocol      basetypes.StringValue         `tfsdk:"protocol"`
+	NodeName      basetypes.StringValue         `tfsdk:"node_name"`
+	Loadbalancers []loadbalancerDataSourceModel `tfsdk:"loadbalancers"`
+}
+
+// loadbalancerPort is a port of the resource, keyed by its name.
+type loadbalancerPort struct {
+	Protocol    string                   `tfsdk:"protocol"`
+	Port        int                      `tfsdk:"port"`
+	NodePort    basetypes.Int64Value     `tfsdk:"nodeport"`
+	HealthCheck *loadbalancerHealthCheck `tfsdk:"health_check"`
+
+	AllowedSourceRanges []string         `tfsdk:"allowed_source_ranges"`
+	TLS                 *loadbalancerTLS `tfsdk:"tls"`
+}
+
+// loadbalancerTLS terminates https and tls ports on the load balancer.
+type loadbalancerTLS struct {
+	CertificateID string                `tfsdk:"certificate_id"`
+	MinVersion    basetypes.StringValue `tfsdk:"min_version"`
+}
+
+// loadbalancerNode is a node of the resource, keyed by its name.
+type loadbalancerNode struct {
+	IP      string                `tfsdk:"ip"`
+	Weight  basetypes.Int64Value  `tfsdk:"weight"`
+	Backup  basetypes.BoolValue   `tfsdk:"backup"`
+	State   basetypes.StringValue `tfsdk:"state"`
+	Healthy basetypes.BoolValue   `tfsdk:"healthy"`
+}
+
+type loadbalancerHealthCheck struct {
+	Type               string                `tfsdk:"type"`
+	Path               basetypes.StringValue `tfsdk:"path"`
+	ExpectedStatus     basetypes.Int64Value  `tfsdk:"expected_status"`
+	Interval           basetypes.Int64Value  `tfsdk:"interval"`
+	Timeout            basetypes.Int64Value  `tfsdk:"timeout"`
+	HealthyThreshold   basetypes.Int64Value  `tfsdk:"healthy_threshold"`
+	UnhealthyThreshold basetypes.Int64Value  `tfsdk:"unhealthy_threshold"`
 }
 
 type loadbalancerResourceModel struct {
-	Ports     []Port                `tfsdk:"ports"`
-	Nodes     []Node                `tfsdk:"nodes"`
-	Namespace string                `tfsdk:"namespace"`
-	Name      string                `tfsdk:"name"`
-	Ip        basetypes.StringValue `tfsdk:"ip"`
+	ID          basetypes.StringValue       `tfsdk:"id"`
+	Ports       map[string]loadbalancerPort `tfsdk:"ports"`
+	Nodes       map[string]loadbalancerNode `tfsdk:"nodes"`
+	Namespace   string                      `tfsdk:"namespace"`
+	Name        string                      `tfsdk:"name"`
+	Ip          basetypes.StringValue       `tfsdk:"ip"`
+	NetworkID   basetypes.Int64Value        `tfsdk:"network_id"`
+	HealthCheck *loadbalancerHealthCheck    `tfsdk:"health_check"`
+
+	Algorithm              basetypes.StringValue `tfsdk:"algorithm"`
+	SessionAffinity        basetypes.StringValue `tfsdk:"session_affinity"`
+	SessionAffinityTimeout basetypes.Int64Value  `tfsdk:"session_affinity_timeout"`
+	DrainTimeout           basetypes.Int64Value  `tfsdk:"drain_timeout"`
+	AllowedSourceRanges    []string              `tfsdk:"allowed_source_ranges"`
+
+	Timeouts *resourceTimeouts `tfsdk:"timeouts"`
+}
+
+// loadbalancerPayload is libvirtApiClient.LoadBalancer with the settings the
+// client does not know about yet.
+type loadbalancerPayload struct {
+	Ports       []loadbalancerPortPayload `json:"ports"`
+	Nodes       []loadbalancerNodePayload `json:"nodes"`
+	Namespace   string                    `json:"namespace"`
+	Name        string                    `json:"name"`
+	Ip          string                    `json:"ip,omitempty"`
+	NetworkID   int64                     `json:"network_id,omitempty"`
+	Status      string                    `json:"status,omitempty"`
+	HealthCheck *healthCheckPayload       `json:"health_check,omitempty"`
+
+	Algorithm              string   `json:"algorithm,omitempty"`
+	SessionAffinity        string   `json:"session_affinity,omitempty"`
+	SessionAffinityTimeout int64    `json:"session_affinity_timeout,omitempty"`
+	AllowedSourceRanges    []string `json:"allowed_source_ranges,omitempty"`
+
+	// NodePortRange is where the server takes omitted nodeports from.
+	NodePortRange string `json:"nodeport_range,omitempty"`
+}
+
+// loadbalancerSettingsPayload is what applies to the whole load balancer.
+// Settings that are unset are sent empty so the server clears them.
+type loadbalancerSettingsPayload struct {
+	HealthCheck            *healthCheckPayload `json:"health_check"`
+	Algorithm              string              `json:"algorithm,omitempty"`
+	SessionAffinity        string              `json:"session_affinity,omitempty"`
+	SessionAffinityTimeout int64               `json:"session_affinity_timeout,omitempty"`
+	AllowedSourceRanges    []string            `json:"allowed_source_ranges"`
+}
+
+type loadbalancerPortPayload struct {
+	libvirtApiClient.Port_Service
+	// NodePort hides the one of Port_Service to leave it out when unset, the
+	// server then assigns one.
+	NodePort            int                 `json:"nodeport,omitempty"`
+	NodePortRange       string              `json:"nodeport_range,omitempty"`
+	HealthCheck         *healthCheckPayload `json:"health_check,omitempty"`
+	AllowedSourceRanges []string            `json:"allowed_source_ranges,omitempty"`
+	TLS                 *tlsPayload         `json:"tls,omitempty"`
+}
+
+type tlsPayload struct {
+	CertificateID string `json:"certificate_id"`
+	MinVersion    string `json:"min_version,omitempty"`
+}
+
+type loadbalancerNodePayload struct {
+	libvirtApiClient.Node
+	Weight  int64  `json:"weight,omitempty"`
+	Backup  bool   `json:"backup,omitempty"`
+	State   string `json:"state,omitempty"`
+	Healthy *bool  `json:"healthy,omitempty"`
+}
+
+type healthCheckPayload struct {
+	Type               string `json:"type"`
+	Path               string `json:"path,omitempty"`
+	ExpectedStatus     int64  `json:"expected_status,omitempty"`
+	Interval           int64  `json:"interval,omitempty"`
+	Timeout            int64  `json:"timeout,omitempty"`
+	HealthyThreshold   int64  `json:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int64  `json:"unhealthy_threshold,omitempty"`
+}
+
+// ready tells whether the server finished provisioning the load balancer.
+// Servers that do not report a status are ready once the IP is known.
+func (lb *loadbalancerPayload) ready() (bool, error) {
+	switch lb.Status {
+	case "", "ready", "active":
+		return true, nil
+	case "error", "failed":
+		return false, fmt.Errorf("loadbalancer %s is in status %s", loadbalancerID(lb.Namespace, lb.Name), lb.Status)
+	}
+	return false, nil
+}
+
+// loadbalancerID builds the import ID of a load balancer.
+func loadbalancerID(namespace string, name string) string {
+	return namespace + "/" + name
+}
+
+// parseLoadbalancerID splits an ID built by loadbalancerID.
+func parseLoadbalancerID(id string) (string, string, error) {
+	namespace, name, found := strings.Cut(id, "/")
+	if !found || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("expected an ID of the form namespace/name, got %q", id)
+	}
+	return namespace, name, nil
+}
+
+// payload converts the model to what the server expects.
+func (m loadbalancerResourceModel) payload() loadbalancerPayload {
+	var bind_payload loadbalancerPayload = loadbalancerPayload{
+		Name:        m.Name,
+		Namespace:   m.Namespace,
+		Ip:          m.Ip.ValueString(),
+		NetworkID:   m.NetworkID.ValueInt64(),
+		HealthCheck: m.HealthCheck.payload(),
+
+		Algorithm:              m.Algorithm.ValueString(),
+		SessionAffinity:        m.SessionAffinity.ValueString(),
+		SessionAffinityTimeout: m.SessionAffinityTimeout.ValueInt64(),
+		AllowedSourceRanges:    m.AllowedSourceRanges,
+	}
+	// Sorted, so the same model always sends the same payload.
+	for _, name := range sortedKeys(m.Nodes) {
+		bind_payload.Nodes = append(bind_payload.Nodes, m.Nodes[name].payload(name))
+	}
+	for _, name := range sortedKeys(m.Ports) {
+		bind_payload.Ports = append(bind_payload.Ports, m.Ports[name].payload(name))
+	}
+	return bind_payload
+}
+
+func (m loadbalancerResourceModel) settingsPayload() loadbalancerSettingsPayload {
+	settings := loadbalancerSettingsPayload{
+		HealthCheck:            m.HealthCheck.payload(),
+		Algorithm:              m.Algorithm.ValueString(),
+		SessionAffinity:        m.SessionAffinity.ValueString(),
+		SessionAffinityTimeout: m.SessionAffinityTimeout.ValueInt64(),
+		AllowedSourceRanges:    m.AllowedSourceRanges,
+	}
+	if settings.AllowedSourceRanges == nil {
+		settings.AllowedSourceRanges = []string{}
+	}
+	return settings
+}
+
+func (n loadbalancerNode) payload(name string) loadbalancerNodePayload {
+	return loadbalancerNodePayload{
+		Node: libvirtApiClient.Node{
+			Name: name,
+			IP:   n.IP,
+		},
+		Weight: n.Weight.ValueInt64(),
+		Backup: n.Backup.ValueBool(),
+		State:  n.State.ValueString(),
+	}
+}
+
+func (p loadbalancerPort) payload(name string) loadbalancerPortPayload {
+	return loadbalancerPortPayload{
+		Port_Service: libvirtApiClient.Port_Service{
+			Name:     name,
+			Protocol: p.Protocol,
+			Port:     p.Port,
+		},
+		NodePort:            int(p.NodePort.ValueInt64()),
+		HealthCheck:         p.HealthCheck.payload(),
+		AllowedSourceRanges: p.AllowedSourceRanges,
+		TLS:                 p.TLS.payload(),
+	}
+}
+
+func (t *loadbalancerTLS) payload() *tlsPayload {
+	if t == nil {
+		return nil
+	}
+	return &tlsPayload{
+		CertificateID: t.CertificateID,
+		MinVersion:    t.MinVersion.ValueString(),
+	}
+}
+
+func (h *loadbalancerHealthCheck) payload() *healthCheckPayload {
+	if h == nil {
+		return nil
+	}
+	return &healthCheckPayload{
+		Type:               h.Type,
+		Path:               h.Path.ValueString(),
+		ExpectedStatus:     h.ExpectedStatus.ValueInt64(),
+		Interval:           h.Interval.ValueInt64(),
+		Timeout:            h.Timeout.ValueInt64(),
+		HealthyThreshold:   h.HealthyThreshold.ValueInt64(),
+		UnhealthyThreshold: h.UnhealthyThreshold.ValueInt64(),
+	}
+}
+
+// refresh copies what the server knows about the load balancer into the model.
+func (m *loadbalancerResourceModel) refresh(lb *loadbalancerPayload) {
+	m.ID = basetypes.NewStringValue(loadbalancerID(lb.Namespace, lb.Name))
+	m.Ip = basetypes.NewStringValue(lb.Ip)
+	m.Name = lb.Name
+	m.Namespace = lb.Namespace
+	m.NetworkID = optionalInt64(lb.NetworkID)
+	m.HealthCheck = newLoadbalancerHealthCheck(lb.HealthCheck)
+	m.Algorithm = basetypes.NewStringValue(valueOrDefault(lb.Algorithm, defaultAlgorithm))
+	m.SessionAffinity = basetypes.NewStringValue(valueOrDefault(lb.SessionAffinity, defaultSessionAffinity))
+	m.SessionAffinityTimeout = optionalInt64(lb.SessionAffinityTimeout)
+	m.AllowedSourceRanges = lb.AllowedSourceRanges
+	// Only known to the provider, imported and upgraded states have none.
+	if m.DrainTimeout.IsNull() {
+		m.DrainTimeout = basetypes.NewInt64Value(defaultDrainTimeout)
+	}
+	m.Nodes = map[string]loadbalancerNode{}
+	for _, node := range lb.Nodes {
+		healthy := basetypes.NewBoolNull()
+		if node.Healthy != nil {
+			healthy = basetypes.NewBoolValue(*node.Healthy)
+		}
+		weight := node.Weight
+		if weight == 0 {
+			weight = defaultNodeWeight
+		}
+		m.Nodes[node.Name] = loadbalancerNode{
+			IP:      node.IP,
+			Weight:  basetypes.NewInt64Value(weight),
+			Backup:  basetypes.NewBoolValue(node.Backup),
+			State:   basetypes.NewStringValue(valueOrDefault(node.State, defaultNodeState)),
+			Healthy: healthy,
+		}
+	}
+	m.Ports = map[string]loadbalancerPort{}
+	for _, port := range lb.Ports {
+		m.Ports[port.Name] = loadbalancerPort{
+			Protocol:            port.Protocol,
+			Port:                port.Port,
+			NodePort:            basetypes.NewInt64Value(int64(port.NodePort)),
+			HealthCheck:         newLoadbalancerHealthCheck(port.HealthCheck),
+			AllowedSourceRanges: port.AllowedSourceRanges,
+			TLS:                 newLoadbalancerTLS(port.TLS),
+		}
+	}
+}
+
+func newLoadbalancerHealthCheck(h *healthCheckPayload) *loadbalancerHealthCheck {
+	if h == nil {
+		return nil
+	}
+	return &loadbalancerHealthCheck{
+		Type:               h.Type,
+		Path:               optionalString(h.Path),
+		ExpectedStatus:     optionalInt64(h.ExpectedStatus),
+		Interval:           optionalInt64(h.Interval),
+		Timeout:            optionalInt64(h.Timeout),
+		HealthyThreshold:   optionalInt64(h.HealthyThreshold),
+		UnhealthyThreshold: optionalInt64(h.UnhealthyThreshold),
+	}
+}
+
+func newLoadbalancerTLS(t *tlsPayload) *loadbalancerTLS {
+	if t == nil {
+		return nil
+	}
+	return &loadbalancerTLS{
+		CertificateID: t.CertificateID,
+		MinVersion:    optionalString(t.MinVersion),
+	}
+}
+
+// valueOrDefault returns fallback for settings the server left empty.
+func valueOrDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// optionalString maps the zero value the server omits back to null.
+func optionalString(value string) basetypes.StringValue {
+	if value == "" {
+		return basetypes.NewStringNull()
+	}
+	return basetypes.NewStringValue(value)
+}
+
+// optionalInt64 maps the zero value the server omits back to null.
+func optionalInt64(value int64) basetypes.Int64Value {
+	if value == 0 {
+		return basetypes.NewInt64Null()
+	}
+	return basetypes.NewInt64Value(value)
+}
+
+// refresh copies the load balancer into the data source model.
+func (m *loadbalancerDataSourceModel) refresh(lb *loadbalancerPayload) {
+	m.ID = basetypes.NewStringValue(loadbalancerID(lb.Namespace, lb.Name))
+	m.Ip = basetypes.NewStringValue(lb.Ip)
+	m.Name = lb.Name
+	m.Namespace = lb.Namespace
+	m.Nodes = loadbalancerNodes(lb)
+	m.Ports = loadbalancerPorts(lb)
+}
+
+func loadbalancerNodes(lb *loadbalancerPayload) []Node {
+	nodes := []Node{}
+	for _, node := range lb.Nodes {
+
+		nodes = append(nodes, Node{
+			Name: node.Name,
+			IP:   node.IP,
+		})
+	}
+	return nodes
+}
+
+func loadbalancerPorts(lb *loadbalancerPayload) []Port {
+	ports := []Port{}
+	for _, port := range lb.Ports {
+
+		ports = append(ports, Port{
+			Name:     port.Name,
+			Protocol: port.Protocol,
+			Port:     port.Port,
+			NodePort: port.NodePort,
+		})
+	}
+	return ports
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
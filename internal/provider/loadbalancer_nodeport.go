@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// assignNodePorts records the nodeports the server assigned to the ports of m
+// that were planned without one. Allocation is left to the server, it is the
+// only place where two concurrent applies cannot pick the same port. Ports lb
+// does not know yet, or all of them when lb is nil, are left null and read on
+// the next refresh.
+func (m *loadbalancerResourceModel) assignNodePorts(lb *loadbalancerPayload) {
+	assigned := map[string]int{}
+	if lb != nil {
+		for _, port := range lb.Ports {
+			assigned[port.Name] = port.NodePort
+		}
+	}
+
+	for name, port := range m.Ports {
+		if !port.NodePort.IsUnknown() && !port.NodePort.IsNull() {
+			continue
+		}
+		port.NodePort = basetypes.NewInt64Null()
+		if nodePort := assigned[name]; nodePort != 0 {
+			port.NodePort = basetypes.NewInt64Value(int64(nodePort))
+		}
+		m.Ports[name] = port
+	}
+}
+
+// checkNodePorts reports the first port of m whose nodeport is outside r, a
+// server that ignores nodeport_range would otherwise go unnoticed.
+func (m loadbalancerResourceModel) checkNodePorts(r *nodePortRange) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	for _, name := range sortedKeys(m.Ports) {
+		nodePort := m.Ports[name].NodePort
+		if nodePort.IsNull() || nodePort.IsUnknown() {
+			continue
+		}
+		if nodePort.ValueInt64() < r.min || nodePort.ValueInt64() > r.max {
+			return name, fmt.Errorf("the server assigned nodeport %d, outside the nodeport_range %s of the provider", nodePort.ValueInt64(), r)
+		}
+	}
+	return "", nil
+}
+
+// param is r as the API takes it, empty when no range is configured.
+func (r *nodePortRange) param() string {
+	if r == nil {
+		return ""
+	}
+	return r.String()
+}
@@ -3,22 +3,32 @@ package provider
 import (
 	"context"
 	"fmt"
+	"time"
 
-	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
-
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &loadbalancerResource{}
-	_ resource.ResourceWithConfigure   = &loadbalancerResource{}
-	_ resource.ResourceWithImportState = &loadbalancerResource{}
+	_ resource.Resource                     = &loadbalancerResource{}
+	_ resource.ResourceWithConfigure        = &loadbalancerResource{}
+	_ resource.ResourceWithImportState      = &loadbalancerResource{}
+	_ resource.ResourceWithConfigValidators = &loadbalancerResource{}
+	_ resource.ResourceWithModifyPlan       = &loadbalancerResource{}
+	_ resource.ResourceWithUpgradeState     = &loadbalancerResource{}
 )
 
 // NewloadbalancerResource is a helper function to simplify the provider implementation.
@@ -31,18 +41,19 @@ func (r *loadbalancerResource) Configure(_ context.Context, req resource.Configu
 		return
 	}
 
-	client, ok := req.ProviderData.(*libvirtApiClient.Client)
+	data, ok := req.ProviderData.(*libvirtapiResourceData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
-			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *libvirtApiClient.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *libvirtapiResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = data.client
+	r.nodePorts = data.nodePorts
 }
 
 // Metadata returns the resource type name.
@@ -53,9 +64,39 @@ func (r *loadbalancerResource) Metadata(_ context.Context, req resource.Metadata
 // Schema defines the schema for the resource.
 func (r *loadbalancerResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version: 1,
+
 		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "namespace/name of the load balancer, used by terraform import.",
+				Computed:    true,
+
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
 			"ip": schema.StringAttribute{
-				Computed: true,
+				Description: "Address of the load balancer, allocated by the server when not set.",
+				Optional:    true,
+				Computed:    true,
+
+				Validators: []validator.String{
+					ipAddress(),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"network_id": schema.Int64Attribute{
+				Description: "ID of the libvirtapi_network the address is allocated from, chosen by the server when not set.",
+				Optional:    true,
+				Computed:    true,
+
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+					int64planmodifier.RequiresReplaceIfConfigured(),
+				},
 			},
 			"name": schema.StringAttribute{
 				Required: true,
@@ -71,79 +112,286 @@ func (r *loadbalancerResource) Schema(_ context.Context, _ resource.SchemaReques
 					stringplanmodifier.RequiresReplace(),
 				},
 			},
-			"ports": schema.ListNestedAttribute{
-				Required: true,
+			"ports": schema.MapNestedAttribute{
+				Description: "Ports of the load balancer, keyed by port name.",
+				Required:    true,
 
 				NestedObject: schema.NestedAttributeObject{
 					Attributes: map[string]schema.Attribute{
-						"name": schema.StringAttribute{
+						"protocol": schema.StringAttribute{
 							Required: true,
 
-							PlanModifiers: []planmodifier.String{
-								stringplanmodifier.RequiresReplace(),
+							Validators: []validator.String{
+								stringOneOf("tcp", "udp", "sctp", "https", "tls"),
 							},
 						},
-						"protocol": schema.StringAttribute{
-							Required: true,
-						},
 						"port": schema.Int64Attribute{
 							Required: true,
+
+							Validators: []validator.Int64{
+								int64Between(1, 65535),
+							},
 						},
 						"nodeport": schema.Int64Attribute{
-							Required: true,
+							Description: "Port opened on the nodes, within nodeport_range of the provider when it is set. When omitted the server assigns a free one from that range.",
+							Optional:    true,
+							Computed:    true,
+
+							Validators: []validator.Int64{
+								int64Between(1, 65535),
+							},
+							PlanModifiers: []planmodifier.Int64{
+								int64planmodifier.UseStateForUnknown(),
+							},
+						},
+						"health_check":          healthCheckAttribute("Health check of the backends of this port."),
+						"allowed_source_ranges": allowedSourceRangesAttribute("CIDRs allowed to connect to this port, in addition to those of the load balancer."),
+						"tls": schema.SingleNestedAttribute{
+							Description: "Terminates TLS on the load balancer, required by the https and tls protocols. Backends get plain connections.",
+							Optional:    true,
+
+							Attributes: map[string]schema.Attribute{
+								"certificate_id": schema.StringAttribute{
+									Description: "ID of the libvirtapi_certificate served to clients.",
+									Required:    true,
+								},
+								"min_version": schema.StringAttribute{
+									Description: "Oldest TLS version accepted from clients.",
+									Optional:    true,
+
+									Validators: []validator.String{
+										stringOneOf("1.2", "1.3"),
+									},
+								},
+							},
 						},
 					},
 				},
 			},
-			"nodes": schema.ListNestedAttribute{
-				Required: true,
+			"nodes": schema.MapNestedAttribute{
+				Description: "Backends of the load balancer, keyed by node name.",
+				Required:    true,
 				NestedObject: schema.NestedAttributeObject{
 					Attributes: map[string]schema.Attribute{
-						"name": schema.StringAttribute{
-							Required: true,
-						},
 						"ip": schema.StringAttribute{
 							Required: true,
+
+							Validators: []validator.String{
+								ipAddress(),
+							},
+						},
+						"weight": schema.Int64Attribute{
+							Description: "Share of the connections the node gets, relative to the other nodes. Defaults to 1.",
+							Optional:    true,
+							Computed:    true,
+							Default:     int64default.StaticInt64(defaultNodeWeight),
+
+							Validators: []validator.Int64{
+								int64Between(1, 256),
+							},
+						},
+						"backup": schema.BoolAttribute{
+							Description: "Only send traffic to the node when no other node is healthy.",
+							Optional:    true,
+							Computed:    true,
+							Default:     booldefault.StaticBool(false),
+						},
+						"state": schema.StringAttribute{
+							Description: "active (default), draining to keep existing connections but take no new ones, or disabled.",
+							Optional:    true,
+							Computed:    true,
+							Default:     stringdefault.StaticString(defaultNodeState),
+
+							Validators: []validator.String{
+								stringOneOf("active", "draining", "disabled"),
+							},
+						},
+						// Unknown on every update, health can change while
+						// the update is applied.
+						"healthy": schema.BoolAttribute{
+							Description: "Result of the health checks, null when the load balancer has none.",
+							Computed:    true,
 						},
 					},
 				},
 			},
+			"health_check":          healthCheckAttribute("Health check of every port without one of its own."),
+			"allowed_source_ranges": allowedSourceRangesAttribute("CIDRs allowed to connect to every port. Connections from anywhere are allowed when neither the load balancer nor the port sets any."),
+			"algorithm": schema.StringAttribute{
+				Description: "How connections are spread over the nodes: round_robin (default), least_connections, source_hash or random.",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(defaultAlgorithm),
+
+				Validators: []validator.String{
+					stringOneOf("round_robin", "least_connections", "source_hash", "random"),
+				},
+			},
+			"session_affinity": schema.StringAttribute{
+				Description: "none (default), or client_ip to keep sending a client to the same node.",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(defaultSessionAffinity),
+
+				Validators: []validator.String{
+					stringOneOf("none", "client_ip"),
+				},
+			},
+			"drain_timeout": schema.Int64Attribute{
+				Description: "Seconds an active node is left draining before it is removed from the load balancer, 30 by default. The provider waits for it once during apply, however many nodes are removed, within the update timeout.",
+				Optional:    true,
+				Computed:    true,
+				Default:     int64default.StaticInt64(defaultDrainTimeout),
+
+				Validators: []validator.Int64{
+					int64Between(1, 3600),
+				},
+			},
+			"session_affinity_timeout": schema.Int64Attribute{
+				Description: "Seconds a client_ip affinity is kept without traffic.",
+				Optional:    true,
+
+				Validators: []validator.Int64{
+					int64Between(1, 86400),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(),
 		},
 	}
 
 }
 
-func (r *loadbalancerResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var plan loadbalancerResourceModel
-	diags := req.Plan.Get(ctx, &plan)
-	resp.Diagnostics.Append(diags...)
+// allowedSourceRangesAttribute is the allow-list of the load balancer and of its ports.
+func allowedSourceRangesAttribute(description string) schema.ListAttribute {
+	return schema.ListAttribute{
+		Description: description,
+		ElementType: types.StringType,
+		Optional:    true,
 
-	if resp.Diagnostics.HasError() {
+		Validators: []validator.List{
+			cidrList(),
+		},
+	}
+}
+
+// healthCheckAttribute is the health_check block of the load balancer and of its ports.
+func healthCheckAttribute(description string) schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: description,
+		Optional:    true,
+
+		Attributes: map[string]schema.Attribute{
+			"type": schema.StringAttribute{
+				Required: true,
+
+				Validators: []validator.String{
+					stringOneOf("tcp", "http", "https"),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description: "Path requested by http and https checks.",
+				Optional:    true,
+			},
+			"expected_status": schema.Int64Attribute{
+				Description: "Status code of a healthy http and https backend.",
+				Optional:    true,
+
+				Validators: []validator.Int64{
+					int64Between(100, 599),
+				},
+			},
+			"interval": schema.Int64Attribute{
+				Description: "Seconds between two checks.",
+				Optional:    true,
+
+				Validators: []validator.Int64{
+					int64Between(1, 3600),
+				},
+			},
+			"timeout": schema.Int64Attribute{
+				Description: "Seconds before a check fails.",
+				Optional:    true,
+
+				Validators: []validator.Int64{
+					int64Between(1, 3600),
+				},
+			},
+			"healthy_threshold": schema.Int64Attribute{
+				Description: "Successful checks before a backend gets traffic again.",
+				Optional:    true,
+
+				Validators: []validator.Int64{
+					int64Between(1, 100),
+				},
+			},
+			"unhealthy_threshold": schema.Int64Attribute{
+				Description: "Failed checks before a backend stops getting traffic.",
+				Optional:    true,
+
+				Validators: []validator.Int64{
+					int64Between(1, 100),
+				},
+			},
+		},
+	}
+}
+
+// ConfigValidators checks what a single attribute validator cannot see.
+func (r *loadbalancerResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		loadbalancerUniqueValidator{},
+		loadbalancerSessionAffinityValidator{},
+		loadbalancerTLSValidator{},
+	}
+}
+
+// ModifyPlan checks nodeports against the nodeport_range of the provider,
+// which is only known once the provider is configured.
+func (r *loadbalancerResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || r.nodePorts == nil {
 		return
 	}
 
-	var bind_payload libvirtApiClient.LoadBalancer = libvirtApiClient.LoadBalancer{
-		Name:      plan.Name,
-		Namespace: plan.Namespace,
+	var ports types.Map
+	diags := req.Plan.GetAttribute(ctx, path.Root("ports"), &ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || ports.IsNull() || ports.IsUnknown() {
+		return
 	}
-	for _, node := range plan.Nodes {
-		var tmp libvirtApiClient.Node = libvirtApiClient.Node{
-			Name: node.Name,
-			IP:   node.IP,
+
+	for name, element := range ports.Elements() {
+		port, ok := element.(types.Object)
+		if !ok || port.IsNull() || port.IsUnknown() {
+			continue
 		}
-		bind_payload.Nodes = append(bind_payload.Nodes, tmp)
-	}
-	for _, port := range plan.Ports {
-		var tmp libvirtApiClient.Port_Service = libvirtApiClient.Port_Service{
-			Name:     port.Name,
-			Protocol: port.Protocol,
-			Port:     port.Port,
-			NodePort: port.NodePort,
+		nodePort, ok := port.Attributes()["nodeport"].(types.Int64)
+		if !ok || nodePort.IsNull() || nodePort.IsUnknown() {
+			continue
+		}
+		if nodePort.ValueInt64() < r.nodePorts.min || nodePort.ValueInt64() > r.nodePorts.max {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("ports").AtMapKey(name).AtName("nodeport"),
+				"Invalid Attribute Value",
+				fmt.Sprintf("nodeport must be within the nodeport_range %s of the provider, got: %d", r.nodePorts, nodePort.ValueInt64()),
+			)
 		}
-		bind_payload.Ports = append(bind_payload.Ports, tmp)
 	}
+}
 
-	ip, err := r.client.CreateLoadBalancer(bind_payload)
+func (r *loadbalancerResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan loadbalancerResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	payload := plan.payload()
+	payload.NodePortRange = r.nodePorts.param()
+	lb, err := createLoadBalancer(ctx, r.client, payload)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -153,7 +401,31 @@ func (r *loadbalancerResource) Create(ctx context.Context, req resource.CreateRe
 		return
 	}
 
-	plan.Ip = basetypes.NewStringValue(ip)
+	plan.ID = basetypes.NewStringValue(loadbalancerID(plan.Namespace, plan.Name))
+	plan.Ip = basetypes.NewStringValue(lb.Ip)
+	if plan.NetworkID.IsUnknown() {
+		plan.NetworkID = optionalInt64(lb.NetworkID)
+	}
+	plan.assignNodePorts(lb)
+
+	err = r.waitReady(ctx, &plan, plan.Timeouts.create())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating loadbalancer",
+			"Loadbalancer "+plan.ID.ValueString()+" did not become ready: "+err.Error(),
+		)
+		diags = resp.State.Set(ctx, plan)
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	r.refreshHealth(ctx, &plan, &resp.Diagnostics)
+	if name, err := plan.checkNodePorts(r.nodePorts); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("ports").AtMapKey(name).AtName("nodeport"),
+			"Invalid Assigned Nodeport",
+			err.Error(),
+		)
+	}
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -171,28 +443,7 @@ func (r *loadbalancerResource) Read(ctx context.Context, req resource.ReadReques
 		return
 	}
 
-	var bind_payload libvirtApiClient.LoadBalancer = libvirtApiClient.LoadBalancer{
-		Name:      state.Name,
-		Namespace: state.Namespace,
-	}
-	for _, node := range state.Nodes {
-		var tmp libvirtApiClient.Node = libvirtApiClient.Node{
-			Name: node.Name,
-			IP:   node.IP,
-		}
-		bind_payload.Nodes = append(bind_payload.Nodes, tmp)
-	}
-	for _, port := range state.Ports {
-		var tmp libvirtApiClient.Port_Service = libvirtApiClient.Port_Service{
-			Name:     port.Name,
-			Protocol: port.Protocol,
-			Port:     port.Port,
-			NodePort: port.NodePort,
-		}
-		bind_payload.Ports = append(bind_payload.Ports, tmp)
-	}
-
-	lb, _, err := r.client.GetLoadBalancer(bind_payload)
+	lb, exist, err := getLoadBalancer(ctx, r.client, state.Namespace, state.Name)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error Reading lb",
@@ -200,28 +451,13 @@ func (r *loadbalancerResource) Read(ctx context.Context, req resource.ReadReques
 		)
 		return
 	}
-
-	state.Ip = basetypes.NewStringValue(lb.Ip)
-	state.Name = lb.Name
-	state.Namespace = lb.Namespace
-	state.Nodes = []Node{}
-	for _, node := range lb.Nodes {
-
-		state.Nodes = append(state.Nodes, Node{
-			Name: node.Name,
-			IP:   node.IP,
-		})
+	if !exist {
+		// Deleted outside of Terraform, plan a re-create.
+		resp.State.RemoveResource(ctx)
+		return
 	}
-	state.Ports = []Port{}
-	for _, node := range lb.Ports {
 
-		state.Ports = append(state.Ports, Port{
-			Name:     node.Name,
-			Protocol: node.Protocol,
-			Port:     node.Port,
-			NodePort: node.NodePort,
-		})
-	}
+	state.refresh(lb)
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -243,59 +479,68 @@ func (r *loadbalancerResource) Update(ctx context.Context, req resource.UpdateRe
 		return
 	}
 
-	var bind_payload libvirtApiClient.LoadBalancer = libvirtApiClient.LoadBalancer{
-		Name:      plan.Name,
-		Namespace: plan.Namespace,
-	}
-	for _, node := range plan.Nodes {
-		var tmp libvirtApiClient.Node = libvirtApiClient.Node{
-			Name: node.Name,
-			IP:   node.IP,
+	// The update timeout covers the operations, drains included, and the
+	// wait for the load balancer to be ready.
+	timeout := plan.Timeouts.update()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Changes go out one node or port at a time, the state keeps track of
+	// what was applied in case one of them fails.
+	for _, operation := range loadbalancerOperations(state, plan) {
+		tflog.Debug(ctx, "Updating loadbalancer", map[string]interface{}{
+			"id":        state.ID.ValueString(),
+			"operation": operation.String(),
+		})
+
+		err := r.applyOperation(ctx, state, operation)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error update loadbalancer",
+				"Could not "+operation.String()+", unexpected error: "+err.Error(),
+			)
+			diags = resp.State.Set(ctx, state)
+			resp.Diagnostics.Append(diags...)
+			return
 		}
-		bind_payload.Nodes = append(bind_payload.Nodes, tmp)
-	}
-	for _, port := range plan.Ports {
-		var tmp libvirtApiClient.Port_Service = libvirtApiClient.Port_Service{
-			Name:     port.Name,
-			Protocol: port.Protocol,
-			Port:     port.Port,
-			NodePort: port.NodePort,
+		operation.apply(&state)
+
+		err = waitOperation(ctx, operation)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error update loadbalancer",
+				"Interrupted after "+operation.String()+": "+err.Error(),
+			)
+			diags = resp.State.Set(ctx, state)
+			resp.Diagnostics.Append(diags...)
+			return
 		}
-		bind_payload.Ports = append(bind_payload.Ports, tmp)
 	}
 
-	err := r.client.UpdateLoadBalancer(bind_payload)
+	plan.ID = basetypes.NewStringValue(loadbalancerID(plan.Namespace, plan.Name))
+	plan.Ip = state.Ip
+	plan.assignNodePorts(nil)
 
+	err := r.waitReady(ctx, &plan, timeout)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error update loadbalancer",
-			"Could not update loadbalancer, unexpected error: "+err.Error(),
+			"Loadbalancer "+plan.ID.ValueString()+" did not become ready: "+err.Error(),
 		)
+		diags = resp.State.Set(ctx, plan)
+		resp.Diagnostics.Append(diags...)
 		return
 	}
-	state.Name = bind_payload.Name
-	state.Namespace = bind_payload.Namespace
-
-	state.Nodes = []Node{}
-	for _, node := range bind_payload.Nodes {
-
-		state.Nodes = append(state.Nodes, Node{
-			Name: node.Name,
-			IP:   node.IP,
-		})
-	}
-	state.Ports = []Port{}
-	for _, node := range bind_payload.Ports {
-
-		state.Ports = append(state.Ports, Port{
-			Name:     node.Name,
-			Protocol: node.Protocol,
-			Port:     node.Port,
-			NodePort: node.NodePort,
-		})
+	r.refreshHealth(ctx, &plan, &resp.Diagnostics)
+	if name, err := plan.checkNodePorts(r.nodePorts); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("ports").AtMapKey(name).AtName("nodeport"),
+			"Invalid Assigned Nodeport",
+			err.Error(),
+		)
 	}
 
-	diags = resp.State.Set(ctx, state)
+	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 
 	if resp.Diagnostics.HasError() {
@@ -312,36 +557,253 @@ func (r *loadbalancerResource) Delete(ctx context.Context, req resource.DeleteRe
 		return
 	}
 
-	var bind_payload libvirtApiClient.LoadBalancer = libvirtApiClient.LoadBalancer{
-		Name:      state.Name,
-		Namespace: state.Namespace,
+	err := deleteLoadBalancer(ctx, r.client, state.payload())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Deleting LoadBalancer",
+			"Could not delete LoadBalancer, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	err = waitFor(ctx, state.Timeouts.delete(), func(ctx context.Context) (bool, error) {
+		_, exist, err := getLoadBalancer(ctx, r.client, state.Namespace, state.Name)
+		return !exist, err
+	})
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Deleting LoadBalancer",
+			"LoadBalancer "+state.ID.ValueString()+" was not removed: "+err.Error(),
+		)
+		return
 	}
-	for _, node := range state.Nodes {
-		var tmp libvirtApiClient.Node = libvirtApiClient.Node{
-			Name: node.Name,
-			IP:   node.IP,
+}
+
+// waitReady polls the load balancer until the server reports it ready and
+// records the address it ended up with.
+func (r *loadbalancerResource) waitReady(ctx context.Context, m *loadbalancerResourceModel, timeout time.Duration) error {
+	return waitFor(ctx, timeout, func(ctx context.Context) (bool, error) {
+		lb, exist, err := getLoadBalancer(ctx, r.client, m.Namespace, m.Name)
+		if err != nil || !exist {
+			return false, err
 		}
-		bind_payload.Nodes = append(bind_payload.Nodes, tmp)
-	}
-	for _, port := range state.Ports {
-		var tmp libvirtApiClient.Port_Service = libvirtApiClient.Port_Service{
-			Name:     port.Name,
-			Protocol: port.Protocol,
-			Port:     port.Port,
-			NodePort: port.NodePort,
+		ready, err := lb.ready()
+		if ready {
+			m.Ip = basetypes.NewStringValue(lb.Ip)
+			m.assignNodePorts(lb)
 		}
-		bind_payload.Ports = append(bind_payload.Ports, tmp)
+		return ready, err
+	})
+}
+
+// refreshHealth fills the computed health of the nodes after a change, it is
+// unknown until the server has seen the new backends.
+func (r *loadbalancerResource) refreshHealth(ctx context.Context, m *loadbalancerResourceModel, diags *diag.Diagnostics) {
+	lb, exist, err := getLoadBalancer(ctx, r.client, m.Namespace, m.Name)
+	if err != nil || !exist {
+		diags.AddWarning(
+			"Unable to read loadbalancer health",
+			fmt.Sprintf("Node health of %s is refreshed on the next plan: %v", m.ID.ValueString(), err),
+		)
+		lb = &loadbalancerPayload{}
 	}
-	err := r.client.DeleteLoadBalancer(bind_payload)
+
+	healthy := map[string]*bool{}
+	for _, node := range lb.Nodes {
+		healthy[node.Name] = node.Healthy
+	}
+	for name, node := range m.Nodes {
+		node.Healthy = basetypes.NewBoolNull()
+		if value := healthy[name]; value != nil {
+			node.Healthy = basetypes.NewBoolValue(*value)
+		}
+		m.Nodes[name] = node
+	}
+}
+
+// ImportState adopts an existing load balancer, the ID is namespace/name.
+func (r *loadbalancerResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	namespace, name, err := parseLoadbalancerID(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid loadbalancer import ID", err.Error())
+		return
+	}
+
+	lb, exist, err := getLoadBalancer(ctx, r.client, namespace, name)
 	if err != nil {
 		resp.Diagnostics.AddError(
-			"Error Deleting LoadBalancer",
-			"Could not delete LoadBalancer, unexpected error: "+err.Error(),
+			"Error Importing LoadBalancer",
+			"Could not read LoadBalancer "+req.ID+": "+err.Error(),
+		)
+		return
+	}
+	if !exist {
+		resp.Diagnostics.AddError(
+			"Error Importing LoadBalancer",
+			"LoadBalancer "+req.ID+" does not exist",
 		)
 		return
 	}
+
+	var state loadbalancerResourceModel
+	state.refresh(lb)
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
 }
 
-func (r *loadbalancerResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+// loadbalancerUniqueValidator rejects ports and nodes the server would mix up.
+type loadbalancerUniqueValidator struct{}
+
+func (v loadbalancerUniqueValidator) Description(_ context.Context) string {
+	return "port numbers and nodeports must be unique per protocol"
+}
+
+func (v loadbalancerUniqueValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v loadbalancerUniqueValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var ports types.Map
+
+	diags := req.Config.GetAttribute(ctx, path.Root("ports"), &ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Port and node names are map keys, unique already. A tcp and a udp port
+	// can share a number, like a DNS service listening on 53 for both.
+	for _, attribute := range []string{"port", "nodeport"} {
+		checkUniqueAttribute(path.Root("ports"), ports, attribute, portTransport, &resp.Diagnostics)
+	}
+}
+
+// portTransport is the protocol a port listens on, https and tls are served
+// over tcp. It is empty while the protocol is unknown.
+func portTransport(port types.Object) string {
+	protocol, ok := port.Attributes()["protocol"].(types.String)
+	if !ok || protocol.IsNull() || protocol.IsUnknown() {
+		return ""
+	}
+	switch protocol.ValueString() {
+	case "https", "tls":
+		return "tcp"
+	}
+	return protocol.ValueString()
+}
+
+// checkUniqueAttribute adds an error for every object of objects repeating the
+// value of attribute within the same scope. Unknown values and scopes are
+// skipped.
+func checkUniqueAttribute(root path.Path, objects types.Map, attribute string, scope func(types.Object) string, diags *diag.Diagnostics) {
+	if objects.IsNull() || objects.IsUnknown() {
+		return
+	}
+
+	elements := objects.Elements()
+	seen := map[string]string{}
+	for _, key := range sortedKeys(elements) {
+		object, ok := elements[key].(types.Object)
+		if !ok || object.IsNull() || object.IsUnknown() {
+			continue
+		}
+		value, ok := object.Attributes()[attribute]
+		if !ok || value.IsNull() || value.IsUnknown() {
+			continue
+		}
+		within := scope(object)
+		if within == "" {
+			continue
+		}
+
+		id := within + " " + attribute + " " + value.String()
+		if first, found := seen[id]; found {
+			diags.AddAttributeError(
+				root.AtMapKey(key).AtName(attribute),
+				"Duplicate Attribute Value",
+				fmt.Sprintf("%s is already used by %s", id, root.AtMapKey(first).String()),
+			)
+			continue
+		}
+		seen[id] = key
+	}
+}
+
+// loadbalancerSessionAffinityValidator only allows a timeout for client_ip affinity.
+type loadbalancerSessionAffinityValidator struct{}
+
+func (v loadbalancerSessionAffinityValidator) Description(_ context.Context) string {
+	return "session_affinity_timeout requires session_affinity client_ip"
+}
+
+func (v loadbalancerSessionAffinityValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v loadbalancerSessionAffinityValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var affinity types.String
+	var timeout types.Int64
+
+	diags := req.Config.GetAttribute(ctx, path.Root("session_affinity"), &affinity)
+	resp.Diagnostics.Append(diags...)
+	diags = req.Config.GetAttribute(ctx, path.Root("session_affinity_timeout"), &timeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || timeout.IsNull() || affinity.IsUnknown() {
+		return
+	}
+
+	if affinity.ValueString() != "client_ip" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("session_affinity_timeout"),
+			"Invalid Attribute Combination",
+			v.Description(ctx),
+		)
+	}
+}
+
+// loadbalancerTLSValidator requires a tls block exactly on the ports that
+// terminate TLS.
+type loadbalancerTLSValidator struct{}
+
+func (v loadbalancerTLSValidator) Description(_ context.Context) string {
+	return "tls must be set for the https and tls protocols, and only for them"
+}
+
+func (v loadbalancerTLSValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v loadbalancerTLSValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var ports types.Map
+
+	diags := req.Config.GetAttribute(ctx, path.Root("ports"), &ports)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || ports.IsNull() || ports.IsUnknown() {
+		return
+	}
+
+	for name, element := range ports.Elements() {
+		port, ok := element.(types.Object)
+		if !ok || port.IsNull() || port.IsUnknown() {
+			continue
+		}
+		protocol, ok := port.Attributes()["protocol"].(types.String)
+		if !ok || protocol.IsNull() || protocol.IsUnknown() {
+			continue
+		}
+		tls := port.Attributes()["tls"]
+		if tls == nil || tls.IsUnknown() {
+			continue
+		}
+
+		terminated := protocol.ValueString() == "https" || protocol.ValueString() == "tls"
+		if terminated == tls.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("ports").AtMapKey(name).AtName("tls"),
+				"Invalid Attribute Combination",
+				fmt.Sprintf("%s, got protocol %s", v.Description(ctx), protocol.ValueString()),
+			)
+		}
+	}
 }
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// testLoadbalancerConfig is m as the user wrote it, without the attributes
+// the provider computes.
+func testLoadbalancerConfig(m loadbalancerResourceModel) loadbalancerResourceModel {
+	m = m.copy()
+	m.ID = basetypes.NewStringNull()
+	m.Ip = basetypes.NewStringNull()
+	m.Algorithm = basetypes.NewStringNull()
+	m.SessionAffinity = basetypes.NewStringNull()
+	m.DrainTimeout = basetypes.NewInt64Null()
+	for name, node := range m.Nodes {
+		node.Weight = basetypes.NewInt64Null()
+		node.Backup = basetypes.NewBoolNull()
+		node.State = basetypes.NewStringNull()
+		node.Healthy = basetypes.NewBoolNull()
+		m.Nodes[name] = node
+	}
+	return m
+}
+
+func TestLoadbalancerPlanHealth(t *testing.T) {
+	state := testLoadbalancer(map[string]string{"a": "10.0.0.1", "b": "10.0.0.2"}, map[string]int{"web": 80})
+	state.ID = basetypes.NewStringValue("ee/db")
+	state.Ip = basetypes.NewStringValue("10.0.1.5")
+	for name, node := range state.Nodes {
+		node.Healthy = basetypes.NewBoolValue(true)
+		state.Nodes[name] = node
+	}
+
+	proposed := state.copy()
+	node := proposed.Nodes["b"]
+	node.IP = "10.0.0.3"
+	proposed.Nodes["b"] = node
+
+	response := planTestChange(t, NewLoadbalancerResource(), "libvirtapi_loadbalancer", state, testLoadbalancerConfig(proposed), proposed)
+
+	// The server reports health again once the update is applied.
+	var planned loadbalancerResourceModel
+	plannedTestModel(t, NewLoadbalancerResource(), response, &planned)
+	for name, node := range planned.Nodes {
+		if !node.Healthy.IsUnknown() {
+			t.Errorf("node %s: planned healthy %s, want unknown", name, node.Healthy)
+		}
+	}
+}
+
+func TestLoadbalancerPlanNetworkID(t *testing.T) {
+	state := testLoadbalancer(map[string]string{"a": "10.0.0.1"}, map[string]int{"web": 80})
+	state.ID = basetypes.NewStringValue("ee/db")
+	state.Ip = basetypes.NewStringValue("10.0.1.5")
+	// Reported by the server, the address was allocated from that network.
+	state.NetworkID = basetypes.NewInt64Value(4)
+
+	cases := []struct {
+		name        string
+		configured  basetypes.Int64Value
+		wantReplace bool
+	}{
+		{"not configured", basetypes.NewInt64Null(), false},
+		{"configured the same", basetypes.NewInt64Value(4), false},
+		{"configured another", basetypes.NewInt64Value(5), true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			proposed := state.copy()
+			proposed.Algorithm = basetypes.NewStringValue("least_connections")
+			if !c.configured.IsNull() {
+				proposed.NetworkID = c.configured
+			}
+			config := testLoadbalancerConfig(proposed)
+			config.Algorithm = proposed.Algorithm
+			config.NetworkID = c.configured
+
+			response := planTestChange(t, NewLoadbalancerResource(), "libvirtapi_loadbalancer", state, config, proposed)
+			if replace := len(response.RequiresReplace) != 0; replace != c.wantReplace {
+				t.Errorf("got RequiresReplace %v, want a replacement %v", response.RequiresReplace, c.wantReplace)
+			}
+
+			var planned loadbalancerResourceModel
+			plannedTestModel(t, NewLoadbalancerResource(), response, &planned)
+			if want := proposed.NetworkID; !planned.NetworkID.Equal(want) {
+				t.Errorf("planned network_id %s, want %s", planned.NetworkID, want)
+			}
+		})
+	}
+}
+
+func TestLoadbalancerUniqueValidator(t *testing.T) {
+	cases := []struct {
+		name    string
+		ports   map[string]loadbalancerPort
+		wantErr bool
+	}{
+		{
+			name: "same port over tcp and udp",
+			ports: map[string]loadbalancerPort{
+				"dns-tcp": {Protocol: "tcp", Port: 53},
+				"dns-udp": {Protocol: "udp", Port: 53},
+			},
+		},
+		{
+			name: "same port twice over tcp",
+			ports: map[string]loadbalancerPort{
+				"web":     {Protocol: "tcp", Port: 80},
+				"web-alt": {Protocol: "tcp", Port: 80},
+			},
+			wantErr: true,
+		},
+		{
+			name: "https is served over tcp",
+			ports: map[string]loadbalancerPort{
+				"raw": {Protocol: "tcp", Port: 443},
+				"web": {Protocol: "https", Port: 443, TLS: &loadbalancerTLS{CertificateID: "web"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "same nodeport over tcp and udp",
+			ports: map[string]loadbalancerPort{
+				"dns-tcp": {Protocol: "tcp", Port: 53, NodePort: basetypes.NewInt64Value(30053)},
+				"dns-udp": {Protocol: "udp", Port: 53, NodePort: basetypes.NewInt64Value(30053)},
+			},
+		},
+		{
+			name: "same nodeport twice over tcp",
+			ports: map[string]loadbalancerPort{
+				"web": {Protocol: "tcp", Port: 80, NodePort: basetypes.NewInt64Value(30080)},
+				"api": {Protocol: "tcp", Port: 8080, NodePort: basetypes.NewInt64Value(30080)},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := testLoadbalancerConfig(testLoadbalancer(map[string]string{"a": "10.0.0.1"}, nil))
+			m.Ports = c.ports
+
+			var response resource.ValidateConfigResponse
+			loadbalancerUniqueValidator{}.ValidateResource(context.Background(), resource.ValidateConfigRequest{
+				Config: testConfig(t, NewLoadbalancerResource(), m),
+			}, &response)
+			if response.Diagnostics.HasError() != c.wantErr {
+				t.Errorf("got %v, want an error %v", response.Diagnostics, c.wantErr)
+			}
+		})
+	}
+}
+
+// testConfig is model as a configuration of r.
+func testConfig(t *testing.T, r resource.Resource, model interface{}) tfsdk.Config {
+	t.Helper()
+	ctx := context.Background()
+
+	var schema resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schema)
+	state := tfsdk.State{Schema: schema.Schema}
+	if d := state.Set(ctx, model); d.HasError() {
+		t.Fatalf("setting %T: %v", model, d)
+	}
+	return tfsdk.Config{Raw: state.Raw, Schema: schema.Schema}
+}
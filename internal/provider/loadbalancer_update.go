@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// loadbalancerOperation is a single change of a node or a port. Update applies
+// them one by one so backends that did not change keep their connections.
+type loadbalancerOperation struct {
+	action string
+	kind   string
+	name   string
+	body   interface{}
+
+	// apply records the operation in the state once the server accepted it.
+	apply func(m *loadbalancerResourceModel)
+
+	// wait is how long Update pauses after the operation, to let a draining
+	// node finish its connections.
+	wait time.Duration
+}
+
+func (o loadbalancerOperation) String() string {
+	return fmt.Sprintf("%s %s %s", o.action, o.kind, o.name)
+}
+
+// changeAction tells an added object from a changed one.
+func changeAction(found bool) string {
+	if found {
+		return "update"
+	}
+	return "add"
+}
+
+// loadbalancerOperations lists what turns state into plan, in the order it has
+// to be applied: new and changed nodes first so traffic always has somewhere
+// to go, then ports, and finally the removal of ports and nodes. Nodes that
+// still take traffic are all drained before any of them is removed.
+func loadbalancerOperations(state loadbalancerResourceModel, plan loadbalancerResourceModel) []loadbalancerOperation {
+	var operations []loadbalancerOperation
+
+	for _, name := range sortedKeys(plan.Nodes) {
+		node := plan.Nodes[name]
+		current, found := state.Nodes[name]
+		if found && current.equal(node) {
+			continue
+		}
+		name := name
+		operations = append(operations, loadbalancerOperation{
+			action: changeAction(found),
+			kind:   "node",
+			name:   name,
+			body:   node.payload(name),
+			apply: func(m *loadbalancerResourceModel) {
+				node.Healthy = current.Healthy
+				m.Nodes[name] = node
+			},
+		})
+	}
+
+	for _, name := range sortedKeys(plan.Ports) {
+		port := plan.Ports[name]
+		current, found := state.Ports[name]
+		if found && current.equal(port) {
+			continue
+		}
+		name := name
+		operations = append(operations, loadbalancerOperation{
+			action: changeAction(found),
+			kind:   "port",
+			name:   name,
+			body:   port.payload(name),
+			apply: func(m *loadbalancerResourceModel) {
+				// An omitted nodeport is read back once the server assigned it.
+				if port.NodePort.IsUnknown() {
+					port.NodePort = basetypes.NewInt64Null()
+				}
+				m.Ports[name] = port
+			},
+		})
+	}
+
+	for _, name := range sortedKeys(state.Ports) {
+		if _, found := plan.Ports[name]; found {
+			continue
+		}
+		name := name
+		operations = append(operations, loadbalancerOperation{
+			action: "remove",
+			kind:   "port",
+			name:   name,
+			apply: func(m *loadbalancerResourceModel) {
+				delete(m.Ports, name)
+			},
+		})
+	}
+
+	var removed []string
+	for _, name := range sortedKeys(state.Nodes) {
+		if _, found := plan.Nodes[name]; !found {
+			removed = append(removed, name)
+		}
+	}
+
+	// Every node is drained before the first one is removed, so they share
+	// a single drain_timeout.
+	var drained int
+	for _, name := range removed {
+		name := name
+		current := state.Nodes[name]
+		if current.State.ValueString() != "active" {
+			continue
+		}
+		draining := current
+		draining.State = basetypes.NewStringValue("draining")
+		operations = append(operations, loadbalancerOperation{
+			action: "drain",
+			kind:   "node",
+			name:   name,
+			body:   draining.payload(name),
+			apply: func(m *loadbalancerResourceModel) {
+				m.Nodes[name] = draining
+			},
+		})
+		drained++
+	}
+	if drained > 0 {
+		operations[len(operations)-1].wait = time.Duration(plan.DrainTimeout.ValueInt64()) * time.Second
+	}
+
+	for _, name := range removed {
+		name := name
+		operations = append(operations, loadbalancerOperation{
+			action: "remove",
+			kind:   "node",
+			name:   name,
+			apply: func(m *loadbalancerResourceModel) {
+				delete(m.Nodes, name)
+			},
+		})
+	}
+
+	// Runs last, once nodes and ports match the plan. Only the settings are
+	// sent, the nodes and ports keep their connections.
+	if !state.settingsEqual(plan) {
+		operations = append(operations, loadbalancerOperation{
+			action: "update",
+			kind:   "settings",
+			name:   loadbalancerID(plan.Namespace, plan.Name),
+			body:   plan.settingsPayload(),
+			apply: func(m *loadbalancerResourceModel) {
+				m.HealthCheck = plan.HealthCheck
+				m.Algorithm = plan.Algorithm
+				m.SessionAffinity = plan.SessionAffinity
+				m.SessionAffinityTimeout = plan.SessionAffinityTimeout
+				m.AllowedSourceRanges = plan.AllowedSourceRanges
+			},
+		})
+	}
+
+	return operations
+}
+
+// settingsEqual compares what applies to the whole load balancer.
+func (m loadbalancerResourceModel) settingsEqual(other loadbalancerResourceModel) bool {
+	return m.HealthCheck.equal(other.HealthCheck) &&
+		m.Algorithm.Equal(other.Algorithm) &&
+		m.SessionAffinity.Equal(other.SessionAffinity) &&
+		m.SessionAffinityTimeout.Equal(other.SessionAffinityTimeout) &&
+		equalStrings(m.AllowedSourceRanges, other.AllowedSourceRanges)
+}
+
+// equal compares the configurable attributes of two nodes.
+func (n loadbalancerNode) equal(other loadbalancerNode) bool {
+	return n.IP == other.IP &&
+		n.Weight.Equal(other.Weight) &&
+		n.Backup.Equal(other.Backup) &&
+		n.State.Equal(other.State)
+}
+
+// equal compares the configurable attributes of two ports.
+func (p loadbalancerPort) equal(other loadbalancerPort) bool {
+	return p.Protocol == other.Protocol &&
+		p.Port == other.Port &&
+		p.NodePort.Equal(other.NodePort) &&
+		p.HealthCheck.equal(other.HealthCheck) &&
+		equalStrings(p.AllowedSourceRanges, other.AllowedSourceRanges) &&
+		p.TLS.equal(other.TLS)
+}
+
+func (t *loadbalancerTLS) equal(other *loadbalancerTLS) bool {
+	if t == nil || other == nil {
+		return t == other
+	}
+	return *t == *other
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *loadbalancerHealthCheck) equal(other *loadbalancerHealthCheck) bool {
+	if h == nil || other == nil {
+		return h == other
+	}
+	return *h == *other
+}
+
+// applyOperation sends a single operation to the node or port endpoint of lb,
+// settings go to the settings endpoint without any node or port.
+func (r *loadbalancerResource) applyOperation(ctx context.Context, lb loadbalancerResourceModel, operation loadbalancerOperation) error {
+	if operation.kind == "settings" {
+		return updateLoadBalancerSettings(ctx, r.client, lb.Namespace, lb.Name, operation.body.(loadbalancerSettingsPayload))
+	}
+
+	if port, ok := operation.body.(loadbalancerPortPayload); ok {
+		port.NodePortRange = r.nodePorts.param()
+		operation.body = port
+	}
+
+	uri := fmt.Sprintf("/api/lb/%s/%s/%s/%s",
+		url.PathEscape(lb.Namespace), url.PathEscape(lb.Name), operation.kind, url.PathEscape(operation.name))
+
+	method := http.MethodPut
+	if operation.action == "remove" {
+		method = http.MethodDelete
+	}
+	return apiRequest(ctx, r.client, method, uri, operation.body, nil)
+}
+
+// waitOperation pauses after operation for as long as it asks, or until ctx
+// is done.
+func waitOperation(ctx context.Context, operation loadbalancerOperation) error {
+	if operation.wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("the update timeout ran out while waiting %s for the nodes to drain", operation.wait)
+		}
+		return ctx.Err()
+	case <-time.After(operation.wait):
+		return nil
+	}
+}
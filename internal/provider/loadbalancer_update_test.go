@@ -0,0 +1,391 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// testLoadbalancer builds a load balancer with active nodes of weight 1 and
+// tcp ports, nodeports are 30000 + port.
+func testLoadbalancer(nodes map[string]string, ports map[string]int) loadbalancerResourceModel {
+	m := loadbalancerResourceModel{
+		Namespace:       "ee",
+		Name:            "db",
+		Algorithm:       basetypes.NewStringValue(defaultAlgorithm),
+		SessionAffinity: basetypes.NewStringValue(defaultSessionAffinity),
+		DrainTimeout:    basetypes.NewInt64Value(defaultDrainTimeout),
+		Nodes:           map[string]loadbalancerNode{},
+		Ports:           map[string]loadbalancerPort{},
+	}
+	for name, ip := range nodes {
+		m.Nodes[name] = loadbalancerNode{
+			IP:      ip,
+			Weight:  basetypes.NewInt64Value(defaultNodeWeight),
+			Backup:  basetypes.NewBoolValue(false),
+			State:   basetypes.NewStringValue(defaultNodeState),
+			Healthy: basetypes.NewBoolNull(),
+		}
+	}
+	for name, port := range ports {
+		m.Ports[name] = loadbalancerPort{
+			Protocol: "tcp",
+			Port:     port,
+			NodePort: basetypes.NewInt64Value(30000 + int64(port)),
+		}
+	}
+	return m
+}
+
+func operationNames(operations []loadbalancerOperation) string {
+	var names []string
+	for _, operation := range operations {
+		names = append(names, operation.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestLoadbalancerOperations(t *testing.T) {
+	state := testLoadbalancer(
+		map[string]string{"a": "10.0.0.1", "b": "10.0.0.2"},
+		map[string]int{"web": 80, "dns": 53},
+	)
+
+	cases := []struct {
+		name   string
+		state  func(state *loadbalancerResourceModel)
+		change func(plan *loadbalancerResourceModel)
+		want   string
+	}{
+		{
+			name:   "no change",
+			change: func(plan *loadbalancerResourceModel) {},
+			want:   "",
+		},
+		{
+			name: "add node",
+			change: func(plan *loadbalancerResourceModel) {
+				plan.Nodes["c"] = plan.Nodes["a"]
+			},
+			want: "add node c",
+		},
+		{
+			name: "remove active node",
+			change: func(plan *loadbalancerResourceModel) {
+				delete(plan.Nodes, "b")
+			},
+			want: "drain node b, remove node b",
+		},
+		{
+			name: "remove disabled node",
+			state: func(state *loadbalancerResourceModel) {
+				node := state.Nodes["b"]
+				node.State = basetypes.NewStringValue("disabled")
+				state.Nodes["b"] = node
+			},
+			change: func(plan *loadbalancerResourceModel) {
+				delete(plan.Nodes, "b")
+			},
+			want: "remove node b",
+		},
+		{
+			name: "change node weight",
+			change: func(plan *loadbalancerResourceModel) {
+				node := plan.Nodes["a"]
+				node.Weight = basetypes.NewInt64Value(5)
+				plan.Nodes["a"] = node
+			},
+			want: "update node a",
+		},
+		{
+			name: "health does not count as a change",
+			change: func(plan *loadbalancerResourceModel) {
+				node := plan.Nodes["a"]
+				node.Healthy = basetypes.NewBoolUnknown()
+				plan.Nodes["a"] = node
+			},
+			want: "",
+		},
+		{
+			name: "change port",
+			change: func(plan *loadbalancerResourceModel) {
+				port := plan.Ports["web"]
+				port.Port = 8080
+				plan.Ports["web"] = port
+			},
+			want: "update port web",
+		},
+		{
+			name: "settings",
+			change: func(plan *loadbalancerResourceModel) {
+				plan.Algorithm = basetypes.NewStringValue("least_connections")
+			},
+			want: "update settings ee/db",
+		},
+		{
+			name: "everything",
+			change: func(plan *loadbalancerResourceModel) {
+				delete(plan.Nodes, "a")
+				plan.Nodes["c"] = plan.Nodes["b"]
+				delete(plan.Ports, "dns")
+				plan.Ports["https"] = loadbalancerPort{Protocol: "tcp", Port: 443, NodePort: basetypes.NewInt64Unknown()}
+				plan.AllowedSourceRanges = []string{"10.0.0.0/8"}
+			},
+			// Backends are added before any is removed, so traffic always has
+			// somewhere to go, and settings come last.
+			want: "add node c, add port https, remove port dns, drain node a, remove node a, update settings ee/db",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state := state.copy()
+			if c.state != nil {
+				c.state(&state)
+			}
+			plan := state.copy()
+			c.change(&plan)
+
+			operations := loadbalancerOperations(state, plan)
+			if got := operationNames(operations); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+
+			// Applying every operation to the state has to end on the plan,
+			// nodeports the server assigns aside.
+			for _, operation := range operations {
+				operation.apply(&state)
+			}
+			if !reflect.DeepEqual(sortedKeys(state.Nodes), sortedKeys(plan.Nodes)) || !reflect.DeepEqual(sortedKeys(state.Ports), sortedKeys(plan.Ports)) {
+				t.Errorf("applied state has nodes %v and ports %v, want %v and %v",
+					sortedKeys(state.Nodes), sortedKeys(state.Ports), sortedKeys(plan.Nodes), sortedKeys(plan.Ports))
+			}
+			for name, node := range plan.Nodes {
+				if !state.Nodes[name].equal(node) {
+					t.Errorf("node %s: got %+v, want %+v", name, state.Nodes[name], node)
+				}
+			}
+			if !state.settingsEqual(plan) {
+				t.Error("settings were not applied")
+			}
+		})
+	}
+}
+
+func TestLoadbalancerOperationsDrain(t *testing.T) {
+	state := testLoadbalancer(map[string]string{"a": "10.0.0.1", "b": "10.0.0.2", "c": "10.0.0.3", "d": "10.0.0.4"}, map[string]int{"web": 80})
+	node := state.Nodes["d"]
+	node.State = basetypes.NewStringValue("disabled")
+	state.Nodes["d"] = node
+	plan := state.copy()
+	delete(plan.Nodes, "b")
+	delete(plan.Nodes, "c")
+	delete(plan.Nodes, "d")
+	plan.DrainTimeout = basetypes.NewInt64Value(45)
+
+	// The active nodes drain together and the update waits once.
+	operations := loadbalancerOperations(state, plan)
+	want := "drain node b, drain node c, remove node b, remove node c, remove node d"
+	if got := operationNames(operations); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i, operation := range operations {
+		wait := time.Duration(0)
+		if i == 1 {
+			wait = 45 * time.Second
+		}
+		if operation.wait != wait {
+			t.Errorf("%s waits %s, want %s", operation, operation.wait, wait)
+		}
+	}
+
+	body, err := json.Marshal(operations[0].body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"b","ip":"10.0.0.2","weight":1,"state":"draining"}`; string(body) != want {
+		t.Errorf("drain body %s, want %s", body, want)
+	}
+}
+
+func TestWaitOperation(t *testing.T) {
+	operation := loadbalancerOperation{action: "drain", kind: "node", name: "b", wait: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := waitOperation(ctx, operation)
+	if err == nil || !strings.Contains(err.Error(), "update timeout") {
+		t.Errorf("got %v, want the update timeout", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("waited %s past the timeout", waited)
+	}
+
+	if err := waitOperation(context.Background(), loadbalancerOperation{}); err != nil {
+		t.Errorf("got %v without a wait", err)
+	}
+}
+
+func TestLoadbalancerOperationsNodePort(t *testing.T) {
+	state := testLoadbalancer(map[string]string{"a": "10.0.0.1"}, map[string]int{"web": 80})
+	plan := state.copy()
+	plan.Ports["api"] = loadbalancerPort{Protocol: "tcp", Port: 8080, NodePort: basetypes.NewInt64Unknown()}
+
+	operations := loadbalancerOperations(state, plan)
+	if got := operationNames(operations); got != "add port api" {
+		t.Fatalf("got %q", got)
+	}
+
+	// The server picks the nodeport when the body has none.
+	body, err := json.Marshal(operations[0].body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"api","protocol":"tcp","port":8080}`; string(body) != want {
+		t.Errorf("body %s, want %s", body, want)
+	}
+
+	operations[0].apply(&state)
+	if nodePort := state.Ports["api"].NodePort; !nodePort.IsNull() {
+		t.Errorf("nodeport %s in the state before the server assigned one, want null", nodePort)
+	}
+}
+
+func TestAssignNodePorts(t *testing.T) {
+	m := testLoadbalancer(nil, map[string]int{"web": 80})
+	m.Ports["api"] = loadbalancerPort{Protocol: "tcp", Port: 8080, NodePort: basetypes.NewInt64Unknown()}
+	m.Ports["dns"] = loadbalancerPort{Protocol: "udp", Port: 53, NodePort: basetypes.NewInt64Unknown()}
+
+	m.assignNodePorts(&loadbalancerPayload{
+		Ports: []loadbalancerPortPayload{
+			{NodePort: 31000, Port_Service: libvirtApiClient.Port_Service{Name: "web"}},
+			{NodePort: 31001, Port_Service: libvirtApiClient.Port_Service{Name: "api"}},
+		},
+	})
+
+	want := map[string]basetypes.Int64Value{
+		"web": basetypes.NewInt64Value(30080),
+		"api": basetypes.NewInt64Value(31001),
+		"dns": basetypes.NewInt64Null(),
+	}
+	for name, nodePort := range want {
+		if got := m.Ports[name].NodePort; !got.Equal(nodePort) {
+			t.Errorf("port %s: got nodeport %s, want %s", name, got, nodePort)
+		}
+	}
+}
+
+// copy returns m with its own nodes and ports.
+func (m loadbalancerResourceModel) copy() loadbalancerResourceModel {
+	nodes := map[string]loadbalancerNode{}
+	for name, node := range m.Nodes {
+		nodes[name] = node
+	}
+	ports := map[string]loadbalancerPort{}
+	for name, port := range m.Ports {
+		ports[name] = port
+	}
+	m.Nodes = nodes
+	m.Ports = ports
+	return m
+}
+
+// newTestClient is a client of a libvirtApi server that records the requests
+// it gets and answers them with handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*libvirtApiClient.Client, func() []string) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(body)))
+		mu.Unlock()
+		if handler != nil {
+			handler(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	received := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, requests...)
+	}
+	return &libvirtApiClient.Client{HostURL: server.URL, HTTPClient: server.Client()}, received
+}
+
+func TestApplyOperationNodePortRange(t *testing.T) {
+	state := testLoadbalancer(map[string]string{"a": "10.0.0.1"}, nil)
+	plan := state.copy()
+	plan.Ports["api"] = loadbalancerPort{Protocol: "tcp", Port: 8080, NodePort: basetypes.NewInt64Unknown()}
+
+	client, received := newTestClient(t, nil)
+	r := &loadbalancerResource{client: client, nodePorts: &nodePortRange{min: 31000, max: 31999}}
+	for _, operation := range loadbalancerOperations(state, plan) {
+		if err := r.applyOperation(context.Background(), state, operation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `PUT /api/lb/ee/db/port/api {"name":"api","protocol":"tcp","port":8080,"nodeport_range":"31000-31999"}`
+	if requests := received(); len(requests) != 1 || requests[0] != want {
+		t.Errorf("got %q, want %q", requests, want)
+	}
+}
+
+func TestCheckNodePorts(t *testing.T) {
+	m := testLoadbalancer(nil, map[string]int{"web": 80})
+	m.Ports["api"] = loadbalancerPort{Protocol: "tcp", Port: 8080, NodePort: basetypes.NewInt64Null()}
+
+	cases := []struct {
+		name     string
+		r        *nodePortRange
+		wantPort string
+	}{
+		{"no range", nil, ""},
+		{"within", &nodePortRange{min: 30000, max: 30100}, ""},
+		{"outside", &nodePortRange{min: 31000, max: 31999}, "web"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			name, err := m.checkNodePorts(c.r)
+			if name != c.wantPort || (err != nil) != (c.wantPort != "") {
+				t.Errorf("got %q, %v, want %q", name, err, c.wantPort)
+			}
+		})
+	}
+}
+
+func TestApplyOperationSettings(t *testing.T) {
+	state := testLoadbalancer(map[string]string{"a": "10.0.0.1"}, map[string]int{"web": 80})
+	state.AllowedSourceRanges = []string{"10.0.0.0/8"}
+	plan := state.copy()
+	plan.Algorithm = basetypes.NewStringValue("least_connections")
+	plan.AllowedSourceRanges = nil
+
+	client, received := newTestClient(t, nil)
+	r := &loadbalancerResource{client: client}
+	for _, operation := range loadbalancerOperations(state, plan) {
+		if err := r.applyOperation(context.Background(), state, operation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nodes and ports are left out, the cleared ranges are sent empty.
+	want := `PUT /api/lb/ee/db/settings {"health_check":null,"algorithm":"least_connections","session_affinity":"none","allowed_source_ranges":[]}`
+	if requests := received(); len(requests) != 1 || requests[0] != want {
+		t.Errorf("got %q, want %q", requests, want)
+	}
+}
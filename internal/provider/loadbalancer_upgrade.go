@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// loadbalancerResourceModelV0 is the state before ports and nodes were keyed by name.
+type loadbalancerResourceModelV0 struct {
+	ID        basetypes.StringValue `tfsdk:"id"`
+	Ports     []Port                `tfsdk:"ports"`
+	Nodes     []Node                `tfsdk:"nodes"`
+	Namespace string                `tfsdk:"namespace"`
+	Name      string                `tfsdk:"name"`
+	Ip        basetypes.StringValue `tfsdk:"ip"`
+}
+
+// UpgradeState migrates states written by older versions of the provider.
+func (r *loadbalancerResource) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   loadbalancerSchemaV0(),
+			StateUpgrader: upgradeLoadbalancerStateV0,
+		},
+	}
+}
+
+func loadbalancerSchemaV0() *schema.Schema {
+	return &schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+			},
+			"ip": schema.StringAttribute{
+				Computed: true,
+			},
+			"name": schema.StringAttribute{
+				Required: true,
+			},
+			"namespace": schema.StringAttribute{
+				Required: true,
+			},
+			"ports": schema.ListNestedAttribute{
+				Required: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Required: true,
+						},
+						"protocol": schema.StringAttribute{
+							Required: true,
+						},
+						"port": schema.Int64Attribute{
+							Required: true,
+						},
+						"nodeport": schema.Int64Attribute{
+							Required: true,
+						},
+					},
+				},
+			},
+			"nodes": schema.ListNestedAttribute{
+				Required: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Required: true,
+						},
+						"ip": schema.StringAttribute{
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// upgradeLoadbalancerStateV0 turns the ports and nodes lists into maps keyed by name.
+func upgradeLoadbalancerStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior loadbalancerResourceModelV0
+	diags := req.State.Get(ctx, &prior)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state := loadbalancerResourceModel{
+		ID:        prior.ID,
+		Namespace: prior.Namespace,
+		Name:      prior.Name,
+		Ip:        prior.Ip,
+		Ports:     map[string]loadbalancerPort{},
+		Nodes:     map[string]loadbalancerNode{},
+	}
+	if state.ID.IsNull() {
+		state.ID = basetypes.NewStringValue(loadbalancerID(prior.Namespace, prior.Name))
+	}
+	for _, port := range prior.Ports {
+		state.Ports[port.Name] = loadbalancerPort{
+			Protocol: port.Protocol,
+			Port:     port.Port,
+			NodePort: basetypes.NewInt64Value(int64(port.NodePort)),
+		}
+	}
+	for _, node := range prior.Nodes {
+		state.Nodes[node.Name] = loadbalancerNode{
+			IP: node.IP,
+		}
+	}
+
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
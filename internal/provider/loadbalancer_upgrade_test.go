@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// upgradeTestState runs the state upgrade of the provider on a raw JSON state
+// of version 0, the way Terraform does when it loads an old state.
+func upgradeTestState(t *testing.T, r resource.Resource, typeName string, raw string) (tfsdk.State, []*tfprotov6.Diagnostic) {
+	t.Helper()
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := server.UpgradeResourceState(ctx, &tfprotov6.UpgradeResourceStateRequest{
+		TypeName: typeName,
+		Version:  0,
+		RawState: &tfprotov6.RawState{JSON: []byte(raw)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range response.Diagnostics {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			return tfsdk.State{}, response.Diagnostics
+		}
+	}
+
+	var schema resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schema)
+	value, err := response.UpgradedState.Unmarshal(schema.Schema.Type().TerraformType(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tfsdk.State{Raw: value, Schema: schema.Schema}, nil
+}
+
+func TestUpgradeLoadbalancerStateV0(t *testing.T) {
+	cases := []struct {
+		name   string
+		raw    string
+		wantID string
+		wantIP string
+		ports  map[string]loadbalancerPort
+		nodes  map[string]string
+	}{
+		{
+			name: "ports and nodes",
+			raw: `{"id":"ee/db","ip":"10.0.0.5","name":"db","namespace":"ee",
+				"ports":[{"name":"web","protocol":"tcp","port":80,"nodeport":30080},{"name":"dns","protocol":"udp","port":53,"nodeport":30053}],
+				"nodes":[{"name":"a","ip":"1.1.1.1"},{"name":"b","ip":"1.1.1.2"}]}`,
+			wantID: "ee/db",
+			wantIP: "10.0.0.5",
+			ports: map[string]loadbalancerPort{
+				"web": {Protocol: "tcp", Port: 80},
+				"dns": {Protocol: "udp", Port: 53},
+			},
+			nodes: map[string]string{"a": "1.1.1.1", "b": "1.1.1.2"},
+		},
+		{
+			name:   "without id",
+			raw:    `{"id":null,"ip":null,"name":"db","namespace":"ee","ports":[],"nodes":[]}`,
+			wantID: "ee/db",
+			ports:  map[string]loadbalancerPort{},
+			nodes:  map[string]string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state, diags := upgradeTestState(t, NewLoadbalancerResource(), "libvirtapi_loadbalancer", c.raw)
+			if diags != nil {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			var got loadbalancerResourceModel
+			if d := state.Get(context.Background(), &got); d.HasError() {
+				t.Fatalf("reading the upgraded state: %v", d)
+			}
+
+			if got.ID.ValueString() != c.wantID || got.Ip.ValueString() != c.wantIP || got.Name != "db" || got.Namespace != "ee" {
+				t.Errorf("got id %s, ip %s, name %s/%s", got.ID, got.Ip, got.Namespace, got.Name)
+			}
+			if len(got.Ports) != len(c.ports) {
+				t.Errorf("got ports %v, want %v", sortedKeys(got.Ports), sortedKeys(c.ports))
+			}
+			for name, want := range c.ports {
+				port, found := got.Ports[name]
+				if !found || port.Protocol != want.Protocol || port.Port != want.Port {
+					t.Errorf("port %s: got %+v, want %+v", name, port, want)
+				}
+			}
+			if port, found := got.Ports["web"]; found && port.NodePort.ValueInt64() != 30080 {
+				t.Errorf("port web: got nodeport %s, want 30080", port.NodePort)
+			}
+			if len(got.Nodes) != len(c.nodes) {
+				t.Errorf("got nodes %v, want %v", sortedKeys(got.Nodes), sortedKeys(c.nodes))
+			}
+			for name, ip := range c.nodes {
+				if node, found := got.Nodes[name]; !found || node.IP != ip {
+					t.Errorf("node %s: got %+v, want ip %s", name, node, ip)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+)
+
+var (
+	_ datasource.DataSource              = &loadbalancersDataSource{}
+	_ datasource.DataSourceWithConfigure = &loadbalancersDataSource{}
+)
+
+func NewLoadbalancersDataSource() datasource.DataSource {
+	return &loadbalancersDataSource{}
+}
+
+func (d *loadbalancersDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirtApiClient.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *libvirtApiClient.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *loadbalancersDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_loadbalancers"
+}
+
+func (d *loadbalancersDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attributes := loadbalancerComputedAttributes()
+	attributes["name"] = schema.StringAttribute{
+		Computed: true,
+	}
+	attributes["namespace"] = schema.StringAttribute{
+		Computed: true,
+	}
+
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"namespace": schema.StringAttribute{
+				Description: "Only return load balancers of this namespace, all namespaces when unset.",
+				Optional:    true,
+			},
+			"name_prefix": schema.StringAttribute{
+				Description: "Only return load balancers whose name starts with this prefix.",
+				Optional:    true,
+			},
+			"protocol": schema.StringAttribute{
+				Description: "Only return load balancers with a port of this protocol.",
+				Optional:    true,
+			},
+			"node_name": schema.StringAttribute{
+				Description: "Only return load balancers sending traffic to this node.",
+				Optional:    true,
+			},
+			"loadbalancers": schema.ListNestedAttribute{
+				Computed: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: attributes,
+				},
+			},
+		},
+	}
+}
+
+func (d *loadbalancersDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data loadbalancersDataSourceModel
+
+	diags := req.Config.Get(ctx, &data)
+
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	loadbalancers, err := listLoadBalancers(ctx, d.client)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Read loadbalancers",
+			err.Error(),
+		)
+		return
+	}
+
+	data.Loadbalancers = []loadbalancerDataSourceModel{}
+	for i := range loadbalancers {
+		if !data.matches(&loadbalancers[i]) {
+			continue
+		}
+		var loadbalancer loadbalancerDataSourceModel
+		loadbalancer.refresh(&loadbalancers[i])
+		data.Loadbalancers = append(data.Loadbalancers, loadbalancer)
+	}
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// matches reports whether lb passes every filter that is set.
+func (m *loadbalancersDataSourceModel) matches(lb *loadbalancerPayload) bool {
+	if !m.Namespace.IsNull() && lb.Namespace != m.Namespace.ValueString() {
+		return false
+	}
+	if !m.NamePrefix.IsNull() && !strings.HasPrefix(lb.Name, m.NamePrefix.ValueString()) {
+		return false
+	}
+	if !m.Protocol.IsNull() {
+		found := false
+		for _, port := range lb.Ports {
+			found = found || strings.EqualFold(port.Protocol, m.Protocol.ValueString())
+		}
+		if !found {
+			return false
+		}
+	}
+	if !m.NodeName.IsNull() {
+		found := false
+		for _, node := range lb.Nodes {
+			found = found || node.Name == m.NodeName.ValueString()
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
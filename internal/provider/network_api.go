@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+)
+
+// networkPayload is libvirtApiClient.NetworkR with the definition of the
+// network, the client only sends the name.
+type networkPayload struct {
+	libvirtApiClient.NetworkR
+	CIDR      string              `json:"cidr,omitempty"`
+	Gateway   string              `json:"gateway,omitempty"`
+	DHCP      *networkDHCPPayload `json:"dhcp,omitempty"`
+	Domain    string              `json:"domain,omitempty"`
+	Mode      string              `json:"mode,omitempty"`
+	Bridge    string              `json:"bridge,omitempty"`
+	MTU       int64               `json:"mtu,omitempty"`
+	Autostart bool                `json:"autostart"`
+}
+
+type networkDHCPPayload struct {
+	Enabled   bool   `json:"enabled"`
+	Start     string `json:"start,omitempty"`
+	End       string `json:"end,omitempty"`
+	LeaseTime int64  `json:"lease_time,omitempty"`
+}
+
+// getNetwork is GetNetwork of libvirtApiClient that tells a missing network
+// apart from a failed request.
+func getNetwork(ctx context.Context, client *libvirtApiClient.Client, id int) (*networkPayload, bool, error) {
+	var network networkPayload
+	err := apiRequest(ctx, client, http.MethodGet, fmt.Sprintf("/api/network/%d", id), nil, &network)
+	if isNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if network.ID == 0 {
+		return nil, false, nil
+	}
+	return &network, true, nil
+}
+
+// getNetworkByName is GetNetworkByName of libvirtApiClient that tells a
+// missing network apart from a failed request.
+func getNetworkByName(ctx context.Context, client *libvirtApiClient.Client, name string) (*networkPayload, bool, error) {
+	var network networkPayload
+	err := apiRequest(ctx, client, http.MethodGet, "/api/network/name/"+url.PathEscape(name), nil, &network)
+	if isNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if network.ID == 0 {
+		return nil, false, nil
+	}
+	return &network, true, nil
+}
+
+func listNetworks(ctx context.Context, client *libvirtApiClient.Client) ([]networkPayload, error) {
+	var networks []networkPayload
+	err := apiRequest(ctx, client, http.MethodGet, "/api/network", nil, &networks)
+	if err != nil {
+		return nil, err
+	}
+	return networks, nil
+}
+
+func createNetwork(ctx context.Context, client *libvirtApiClient.Client, bind_payload networkPayload) (*networkPayload, error) {
+	var network networkPayload
+	err := apiRequest(ctx, client, http.MethodPost, "/api/network", bind_payload, &network)
+	if err != nil {
+		return nil, err
+	}
+	return &network, nil
+}
+
+func updateNetwork(ctx context.Context, client *libvirtApiClient.Client, bind_payload networkPayload) (*networkPayload, error) {
+	var network networkPayload
+	err := apiRequest(ctx, client, http.MethodPut, "/api/network", bind_payload, &network)
+	if err != nil {
+		return nil, err
+	}
+	return &network, nil
+}
+
+func deleteNetwork(ctx context.Context, client *libvirtApiClient.Client, id int) error {
+	return apiRequest(ctx, client, http.MethodDelete, fmt.Sprintf("/api/network/%d", id), nil, nil)
+}
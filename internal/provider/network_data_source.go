@@ -3,12 +3,12 @@ package provider
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -16,14 +16,19 @@ type networkDataSource struct {
 	client *libvirtApiClient.Client
 }
 type networkDataSourceModel struct {
-	ID     types.Int64 `tfsdk:"id"`
-	Name   string      `tfsdk:"name"`
-	Status types.Int64 `tfsdk:"status"`
+	ID     types.Int64  `tfsdk:"id"`
+	Name   types.String `tfsdk:"name"`
+	Status types.String `tfsdk:"status"`
+	CIDR   types.String `tfsdk:"cidr"`
+	Mode   types.String `tfsdk:"mode"`
+	Bridge types.String `tfsdk:"bridge"`
+	Active types.Bool   `tfsdk:"active"`
 }
 
 var (
-	_ datasource.DataSource              = &networkDataSource{}
-	_ datasource.DataSourceWithConfigure = &networkDataSource{}
+	_ datasource.DataSource                     = &networkDataSource{}
+	_ datasource.DataSourceWithConfigure        = &networkDataSource{}
+	_ datasource.DataSourceWithConfigValidators = &networkDataSource{}
 )
 
 // Configure adds the provider configured client to the data source.
@@ -55,20 +60,58 @@ func NewNetworkDataSource() datasource.DataSource {
 }
 
 func (d *networkDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attributes := networkComputedAttributes()
+	attributes["id"] = schema.Int64Attribute{
+		Optional: true,
+		Computed: true,
+	}
+	attributes["name"] = schema.StringAttribute{
+		Optional: true,
+		Computed: true,
+	}
+
 	resp.Schema = schema.Schema{
-		Attributes: map[string]schema.Attribute{
-			"id": schema.Int64Attribute{
-				Required: true,
-			},
-			"name": schema.StringAttribute{
-				Required: true,
-			},
-			"status": schema.Int64Attribute{
-				Required: true,
-			},
+		Description: "Looks a network up by id or by name.",
+		Attributes:  attributes,
+	}
+}
+
+// networkComputedAttributes returns the attributes filled from the server,
+// they are shared with libvirtapi_networks.
+func networkComputedAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"id": schema.Int64Attribute{
+			Computed: true,
+		},
+		"name": schema.StringAttribute{
+			Computed: true,
+		},
+		"status": schema.StringAttribute{
+			Description: "active, inactive, or unknown for a status the provider does not know.",
+			Computed:    true,
+		},
+		"cidr": schema.StringAttribute{
+			Computed: true,
+		},
+		"mode": schema.StringAttribute{
+			Computed: true,
 		},
+		"bridge": schema.StringAttribute{
+			Computed: true,
+		},
+		"active": schema.BoolAttribute{
+			Description: "Whether the network is running.",
+			Computed:    true,
+		},
+	}
+}
+
+func (d *networkDataSource) ConfigValidators(_ context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		networkLookupValidator{},
 	}
 }
+
 func (d *networkDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var data networkDataSourceModel
 
@@ -79,7 +122,16 @@ func (d *networkDataSource) Read(ctx context.Context, req datasource.ReadRequest
 		return
 	}
 
-	network, err := d.client.GetNetwork(int(data.ID.ValueInt64()))
+	var network *networkPayload
+	var exist bool
+	var err error
+	lookup := "ID " + data.ID.String()
+	if !data.ID.IsNull() {
+		network, exist, err = getNetwork(ctx, d.client, int(data.ID.ValueInt64()))
+	} else {
+		lookup = "name " + data.Name.String()
+		network, exist, err = getNetworkByName(ctx, d.client, data.Name.ValueString())
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Read Network ",
@@ -87,32 +139,58 @@ func (d *networkDataSource) Read(ctx context.Context, req datasource.ReadRequest
 		)
 		return
 	}
-
-	if network.Name != data.Name {
-
-		data_string := network.Name + " != " + data.Name
+	if !exist {
 		resp.Diagnostics.AddError(
-			"Bad name:", data_string,
+			"Network not found",
+			"No network with "+lookup,
 		)
 		return
 	}
 
-	if types.Int64Value(int64(network.Status)) != data.Status {
+	data.refresh(network)
 
-		data_string := strconv.Itoa(network.Status) + " != " + data.Status.String()
-		resp.Diagnostics.AddError(
-			"Bad Status:", data_string,
-		)
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
+}
+
+// refresh copies the network into the data source model.
+func (m *networkDataSourceModel) refresh(network *networkPayload) {
+	m.ID = types.Int64Value(int64(network.ID))
+	m.Name = types.StringValue(network.Name)
+	m.Status = types.StringValue(networkStatus(network.Status))
+	m.CIDR = optionalString(network.CIDR)
+	m.Mode = types.StringValue(valueOrDefault(network.Mode, defaultNetworkMode))
+	m.Bridge = optionalString(network.Bridge)
+	m.Active = types.BoolValue(network.Status == networkStatusActive)
+}
 
-	// data.ID = types.Int64Value(int64(network.ID))
-	// data.Name = network.Name
-	// data.Status = types.Int64Value(int64(network.Status))
+// networkLookupValidator requires exactly one of id and name.
+type networkLookupValidator struct{}
 
-	diags = resp.State.Set(ctx, &data)
+func (v networkLookupValidator) Description(_ context.Context) string {
+	return "exactly one of id and name must be set"
+}
+
+func (v networkLookupValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v networkLookupValidator) ValidateDataSource(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var id types.Int64
+	var name types.String
+
+	diags := req.Config.GetAttribute(ctx, path.Root("id"), &id)
 	resp.Diagnostics.Append(diags...)
-	if resp.Diagnostics.HasError() {
+	diags = req.Config.GetAttribute(ctx, path.Root("name"), &name)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || id.IsUnknown() || name.IsUnknown() {
 		return
 	}
+
+	if id.IsNull() == name.IsNull() {
+		resp.Diagnostics.AddError("Invalid Attribute Combination", v.Description(ctx))
+	}
 }
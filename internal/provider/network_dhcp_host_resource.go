@@ -0,0 +1,348 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &networkDHCPHostResource{}
+	_ resource.ResourceWithConfigure   = &networkDHCPHostResource{}
+	_ resource.ResourceWithImportState = &networkDHCPHostResource{}
+	_ resource.ResourceWithModifyPlan  = &networkDHCPHostResource{}
+)
+
+// NewNetworkDHCPHostResource is a helper function to simplify the provider implementation.
+func NewNetworkDHCPHostResource() resource.Resource {
+	return &networkDHCPHostResource{}
+}
+
+// networkDHCPHostResource is the resource implementation.
+type networkDHCPHostResource struct {
+	client *libvirtApiClient.Client
+}
+
+type networkDHCPHostResourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	NetworkID int64        `tfsdk:"network_id"`
+	MAC       string       `tfsdk:"mac"`
+	IP        types.String `tfsdk:"ip"`
+	Hostname  types.String `tfsdk:"hostname"`
+}
+
+// networkDHCPHostPayload is the body of the /api/network/{id}/dhcp/hosts
+// endpoints.
+type networkDHCPHostPayload struct {
+	MAC      string `json:"mac"`
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname,omitempty"`
+}
+
+func (r *networkDHCPHostResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*libvirtapiResourceData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *libvirtapiResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = data.client
+}
+
+// Metadata returns the resource type name.
+func (r *networkDHCPHostResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_network_dhcp_host"
+}
+
+// Schema defines the schema for the resource.
+func (r *networkDHCPHostResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Static DHCP reservation of a libvirtapi_network.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "network_id/mac",
+				Computed:    true,
+
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"network_id": schema.Int64Attribute{
+				Required: true,
+
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"mac": schema.StringAttribute{
+				Required: true,
+
+				Validators: []validator.String{
+					macAddress(),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ip": schema.StringAttribute{
+				Description: "Address handed to mac, within the cidr of the network.",
+				Required:    true,
+
+				Validators: []validator.String{
+					ipAddress(),
+				},
+			},
+			"hostname": schema.StringAttribute{
+				Optional: true,
+
+				Validators: []validator.String{
+					stringMatches(domainName, "a host name like web-1"),
+				},
+			},
+		},
+	}
+}
+
+// ModifyPlan checks ip against the cidr of the network, which is only known
+// to the server.
+func (r *networkDHCPHostResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	var networkID types.Int64
+	var ip types.String
+	diags := req.Plan.GetAttribute(ctx, path.Root("network_id"), &networkID)
+	resp.Diagnostics.Append(diags...)
+	diags = req.Plan.GetAttribute(ctx, path.Root("ip"), &ip)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || networkID.IsUnknown() || ip.IsUnknown() {
+		return
+	}
+
+	network, exist, err := getNetwork(ctx, r.client, int(networkID.ValueInt64()))
+	if err != nil || !exist {
+		// Not created yet, or not reachable: Create checks it again.
+		return
+	}
+	attribute, err := checkDHCPHostNetwork(network, ip.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root(attribute), "Invalid Attribute Value", err.Error())
+	}
+}
+
+// checkDHCPHostNetwork reports whether ip can be reserved in network, and
+// which attribute is wrong when it cannot.
+func checkDHCPHostNetwork(network *networkPayload, ip string) (string, error) {
+	if network.CIDR == "" {
+		return "network_id", fmt.Errorf("network %d has no cidr to reserve addresses in", network.ID)
+	}
+	_, cidr, err := net.ParseCIDR(network.CIDR)
+	if err != nil {
+		return "", nil
+	}
+	if address := net.ParseIP(ip); address != nil && !cidr.Contains(address) {
+		return "ip", fmt.Errorf("ip must be within cidr %s of network %d, got: %s", network.CIDR, network.ID, ip)
+	}
+	return "", nil
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *networkDHCPHostResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan networkDHCPHostResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The network is unknown at plan time when it is created in the same
+	// apply, so ModifyPlan may not have checked ip.
+	network, exist, err := getNetwork(ctx, r.client, int(plan.NetworkID))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating network dhcp host",
+			fmt.Sprintf("Could not read network %d: %s", plan.NetworkID, err.Error()),
+		)
+		return
+	}
+	if !exist {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("network_id"),
+			"Error creating network dhcp host",
+			fmt.Sprintf("Network %d does not exist.", plan.NetworkID),
+		)
+		return
+	}
+	attribute, err := checkDHCPHostNetwork(network, plan.IP.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root(attribute), "Invalid Attribute Value", err.Error())
+		return
+	}
+
+	err = apiRequest(ctx, r.client, http.MethodPost, fmt.Sprintf("/api/network/%d/dhcp/hosts", plan.NetworkID), plan.payload(), nil)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating network dhcp host",
+			"Could not create network dhcp host, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.ID = types.StringValue(networkDHCPHostID(plan.NetworkID, plan.MAC))
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *networkDHCPHostResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state networkDHCPHostResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var host networkDHCPHostPayload
+	err := apiRequest(ctx, r.client, http.MethodGet, state.uri(), nil, &host)
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading network dhcp host",
+			"Could not read network dhcp host "+state.ID.ValueString()+": "+err.Error(),
+		)
+		return
+	}
+
+	state.ID = types.StringValue(networkDHCPHostID(state.NetworkID, state.MAC))
+	state.IP = types.StringValue(host.IP)
+	state.Hostname = optionalString(host.Hostname)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *networkDHCPHostResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan networkDHCPHostResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := apiRequest(ctx, r.client, http.MethodPut, plan.uri(), plan.payload(), nil)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Update network dhcp host",
+			"Could not update network dhcp host, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.ID = types.StringValue(networkDHCPHostID(plan.NetworkID, plan.MAC))
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *networkDHCPHostResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state networkDHCPHostResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := apiRequest(ctx, r.client, http.MethodDelete, state.uri(), nil, nil)
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError(
+			"Error Deleting network dhcp host",
+			"Could not delete network dhcp host, unexpected error: "+err.Error(),
+		)
+		return
+	}
+}
+
+func (r *networkDHCPHostResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	networkID, mac, err := parseNetworkDHCPHostID(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid Import ID", err.Error())
+		return
+	}
+
+	diags := resp.State.SetAttribute(ctx, path.Root("id"), req.ID)
+	resp.Diagnostics.Append(diags...)
+	diags = resp.State.SetAttribute(ctx, path.Root("network_id"), networkID)
+	resp.Diagnostics.Append(diags...)
+	diags = resp.State.SetAttribute(ctx, path.Root("mac"), mac)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (m networkDHCPHostResourceModel) payload() networkDHCPHostPayload {
+	return networkDHCPHostPayload{
+		MAC:      m.MAC,
+		IP:       m.IP.ValueString(),
+		Hostname: m.Hostname.ValueString(),
+	}
+}
+
+func (m networkDHCPHostResourceModel) uri() string {
+	return fmt.Sprintf("/api/network/%d/dhcp/hosts/%s", m.NetworkID, url.PathEscape(m.MAC))
+}
+
+// networkDHCPHostID builds the import ID of a reservation.
+func networkDHCPHostID(networkID int64, mac string) string {
+	return strconv.FormatInt(networkID, 10) + "/" + mac
+}
+
+// parseNetworkDHCPHostID splits an ID built by networkDHCPHostID.
+func parseNetworkDHCPHostID(id string) (int64, string, error) {
+	network, mac, found := strings.Cut(id, "/")
+	networkID, err := strconv.ParseInt(network, 10, 64)
+	if !found || err != nil || mac == "" {
+		return 0, "", fmt.Errorf("expected an ID of the form network_id/mac, got %q", id)
+	}
+	return networkID, strings.ToLower(mac), nil
+}
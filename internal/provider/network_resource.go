@@ -1,22 +1,35 @@
 package provider
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
 
 	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
 var (
-	_ resource.Resource                = &networkResource{}
-	_ resource.ResourceWithConfigure   = &networkResource{}
-	_ resource.ResourceWithImportState = &networkResource{}
+	_ resource.Resource                     = &networkResource{}
+	_ resource.ResourceWithConfigure        = &networkResource{}
+	_ resource.ResourceWithImportState      = &networkResource{}
+	_ resource.ResourceWithConfigValidators = &networkResource{}
+	_ resource.ResourceWithUpgradeState     = &networkResource{}
 )
 
 // NewnetworkResource is a helper function to simplify the provider implementation.
@@ -29,29 +42,69 @@ type networkResource struct {
 	client *libvirtApiClient.Client
 }
 
+// networkStatusActive is the libvirt status of a running network.
+const networkStatusActive = 1
+
+// networkStatus names the status the server reports.
+func networkStatus(status int) string {
+	switch status {
+	case 0:
+		return "inactive"
+	case networkStatusActive:
+		return "active"
+	}
+	return "unknown"
+}
+
 type networkResourceModel struct {
-	ID     types.Int64 `tfsdk:"id"`
-	Name   string      `tfsdk:"name"`
-	Status types.Int64 `tfsdk:"status"`
+	ID        types.Int64  `tfsdk:"id"`
+	Name      string       `tfsdk:"name"`
+	Status    types.String `tfsdk:"status"`
+	Active    types.Bool   `tfsdk:"active"`
+	CIDR      types.String `tfsdk:"cidr"`
+	Gateway   types.String `tfsdk:"gateway"`
+	DHCP      *networkDHCP `tfsdk:"dhcp"`
+	Domain    types.String `tfsdk:"domain"`
+	Mode      types.String `tfsdk:"mode"`
+	Bridge    types.String `tfsdk:"bridge"`
+	MTU       types.Int64  `tfsdk:"mtu"`
+	Autostart types.Bool   `tfsdk:"autostart"`
+
+	Timeouts *resourceTimeouts `tfsdk:"timeouts"`
 }
 
+type networkDHCP struct {
+	Enabled   types.Bool   `tfsdk:"enabled"`
+	Start     types.String `tfsdk:"start"`
+	End       types.String `tfsdk:"end"`
+	LeaseTime types.Int64  `tfsdk:"lease_time"`
+}
+
+const defaultNetworkMode = "nat"
+
+var (
+	// bridgeName is what Linux accepts as an interface name.
+	bridgeName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,15}$`)
+	domainName = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+)
+
 func (r *networkResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*libvirtApiClient.Client)
+	data, ok := req.ProviderData.(*libvirtapiResourceData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
-			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *libvirtApiClient.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *libvirtapiResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = data.client
 }
 
 // Metadata returns the resource type name.
@@ -60,19 +113,157 @@ func (r *networkResource) Metadata(_ context.Context, req resource.MetadataReque
 }
 
 // Schema defines the schema for the resource.
+//
+// Changing name, dhcp, autostart or active updates the network in place. Everything
+// else changes the libvirt definition in ways that need the network to be
+// recreated, and forces a replacement.
 func (r *networkResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version: 1,
 		Attributes: map[string]schema.Attribute{
 			"id": schema.Int64Attribute{
 				Computed: true,
+
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
 			"name": schema.StringAttribute{
 				Required: true,
 			},
-			"status": schema.Int64Attribute{
-				Computed: true,
+			"status": schema.StringAttribute{
+				Description: "active, inactive, or unknown for a status the provider does not know.",
+				Computed:    true,
+			},
+			"active": schema.BoolAttribute{
+				Description: "Whether the network runs. The network is started or stopped to match, defaults to true.",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
+			},
+			"cidr": schema.StringAttribute{
+				Description: "Address range of the network, e.g. 192.168.100.0/24. Isolated and open networks may leave it unset.",
+				Optional:    true,
+
+				Validators: []validator.String{
+					cidr(),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"gateway": schema.StringAttribute{
+				Description: "Address of the host on the network, the first address of cidr when not set.",
+				Optional:    true,
+				Computed:    true,
+
+				Validators: []validator.String{
+					ipAddress(),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"dhcp": schema.SingleNestedAttribute{
+				Description: "DHCP server of the network, within cidr.",
+				Optional:    true,
+
+				Attributes: map[string]schema.Attribute{
+					"enabled": schema.BoolAttribute{
+						Optional: true,
+						Computed: true,
+						Default:  booldefault.StaticBool(true),
+					},
+					"start": schema.StringAttribute{
+						Description: "First address handed out.",
+						Optional:    true,
+
+						Validators: []validator.String{
+							ipAddress(),
+						},
+					},
+					"end": schema.StringAttribute{
+						Description: "Last address handed out.",
+						Optional:    true,
+
+						Validators: []validator.String{
+							ipAddress(),
+						},
+					},
+					"lease_time": schema.Int64Attribute{
+						Description: "Lease time in seconds.",
+						Optional:    true,
+
+						Validators: []validator.Int64{
+							int64Between(120, 31536000),
+						},
+					},
+				},
+			},
+			"domain": schema.StringAttribute{
+				Description: "DNS domain of the network.",
+				Optional:    true,
+
+				Validators: []validator.String{
+					stringMatches(domainName, "a DNS domain like example.internal"),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"mode": schema.StringAttribute{
+				Description: "Forward mode: nat (default), route, bridge, isolated or open.",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(defaultNetworkMode),
+
+				Validators: []validator.String{
+					stringOneOf("nat", "route", "bridge", "isolated", "open"),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"bridge": schema.StringAttribute{
+				Description: "Bridge device of the network. Required for mode bridge, where it is an existing bridge of the host, allocated by libvirt otherwise.",
+				Optional:    true,
+				Computed:    true,
+
+				Validators: []validator.String{
+					stringMatches(bridgeName, "an interface name of at most 15 letters, digits, '_', '.' or '-'"),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"mtu": schema.Int64Attribute{
+				Optional: true,
+
+				Validators: []validator.Int64{
+					int64Between(68, 65535),
+				},
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"autostart": schema.BoolAttribute{
+				Description: "Start the network when the host boots.",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(),
+		},
+	}
+}
+
+func (r *networkResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		networkDefinitionValidator{},
 	}
 }
 
@@ -86,7 +277,7 @@ func (r *networkResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
-	network, err := r.client.CreateNetwork(plan.Name)
+	network, err := createNetwork(ctx, r.client, plan.payload())
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -98,7 +289,19 @@ func (r *networkResource) Create(ctx context.Context, req resource.CreateRequest
 
 	plan.ID = types.Int64Value(int64(network.ID))
 	plan.Name = network.Name
-	plan.Status = types.Int64Value(int64(network.Status))
+	plan.Status = types.StringValue(networkStatus(network.Status))
+	plan.allocated(network)
+
+	err = r.applyActive(ctx, &plan, plan.Timeouts.create())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating network",
+			fmt.Sprintf("Network %d did not become %s: %s", plan.ID.ValueInt64(), plan.wantedStatus(), err),
+		)
+		diags = resp.State.Set(ctx, plan)
+		resp.Diagnostics.Append(diags...)
+		return
+	}
 
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
@@ -117,7 +320,7 @@ func (r *networkResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
-	network, err := r.client.GetNetwork(int(state.ID.ValueInt64()))
+	network, exist, err := getNetwork(ctx, r.client, int(state.ID.ValueInt64()))
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error Reading Network",
@@ -125,10 +328,13 @@ func (r *networkResource) Read(ctx context.Context, req resource.ReadRequest, re
 		)
 		return
 	}
+	if !exist {
+		// Deleted outside of Terraform, plan a re-create.
+		resp.State.RemoveResource(ctx)
+		return
+	}
 
-	state.ID = types.Int64Value(int64(network.ID))
-	state.Name = network.Name
-	state.Status = types.Int64Value(int64(network.Status))
+	state.refresh(network)
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -140,17 +346,20 @@ func (r *networkResource) Read(ctx context.Context, req resource.ReadRequest, re
 
 // Update updates the resource and sets the updated Terraform state on success.
 func (r *networkResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state networkResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+
 	var plan networkResourceModel
-	diags := req.State.Get(ctx, &plan)
+	diags = req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	updateReq := libvirtApiClient.NetworkR{
-		Name: plan.Name,
-	}
-	new_network, err := r.client.UpdateNetwork(updateReq)
+	updateReq := plan.payload()
+	updateReq.ID = int(state.ID.ValueInt64())
+	new_network, err := updateNetwork(ctx, r.client, updateReq)
 
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -160,9 +369,21 @@ func (r *networkResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
-	plan.ID = types.Int64Value(int64(new_network.ID))
+	plan.ID = state.ID
 	plan.Name = new_network.Name
-	plan.Status = types.Int64Value(int64(new_network.Status))
+	plan.Status = types.StringValue(networkStatus(new_network.Status))
+	plan.allocated(new_network)
+
+	err = r.applyActive(ctx, &plan, plan.Timeouts.update())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Update Network",
+			fmt.Sprintf("Network %d did not become %s: %s", plan.ID.ValueInt64(), plan.wantedStatus(), err),
+		)
+		diags = resp.State.Set(ctx, &plan)
+		resp.Diagnostics.Append(diags...)
+		return
+	}
 
 	diags = resp.State.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -181,7 +402,10 @@ func (r *networkResource) Delete(ctx context.Context, req resource.DeleteRequest
 	}
 
 	// Delete existing Network
-	err := r.client.DeleteNetwork(int(state.ID.ValueInt64()))
+	err := deleteNetwork(ctx, r.client, int(state.ID.ValueInt64()))
+	if isNotFound(err) {
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Error Deleting Network",
@@ -189,9 +413,204 @@ func (r *networkResource) Delete(ctx context.Context, req resource.DeleteRequest
 		)
 		return
 	}
+
+	err = waitFor(ctx, state.Timeouts.delete(), func(ctx context.Context) (bool, error) {
+		_, exist, err := getNetwork(ctx, r.client, int(state.ID.ValueInt64()))
+		return !exist, err
+	})
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Deleting Network",
+			fmt.Sprintf("Network %d was not removed: %s", state.ID.ValueInt64(), err),
+		)
+		return
+	}
+}
+
+// applyActive starts or stops the network as m.Active asks, and polls it
+// until libvirt reports the change.
+func (r *networkResource) applyActive(ctx context.Context, m *networkResourceModel, timeout time.Duration) error {
+	id := int(m.ID.ValueInt64())
+	wanted := m.wantedStatus()
+	if m.Status.ValueString() != wanted {
+		action := "start"
+		if wanted != "active" {
+			action = "stop"
+		}
+		err := apiRequest(ctx, r.client, http.MethodPost, fmt.Sprintf("/api/network/%d/%s", id, action), nil, nil)
+		if err != nil {
+			return fmt.Errorf("could not %s network: %w", action, err)
+		}
+	}
+
+	return waitFor(ctx, timeout, func(ctx context.Context) (bool, error) {
+		network, exist, err := getNetwork(ctx, r.client, id)
+		if err != nil || !exist {
+			return false, err
+		}
+		m.Status = types.StringValue(networkStatus(network.Status))
+		return m.Status.ValueString() == wanted, nil
+	})
+}
+
+func (m networkResourceModel) wantedStatus() string {
+	if m.Active.ValueBool() {
+		return "active"
+	}
+	return "inactive"
 }
 
 func (r *networkResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	// Retrieve import ID and save to id attribute
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
+
+// payload converts the model to what the server expects.
+func (m networkResourceModel) payload() networkPayload {
+	bind_payload := networkPayload{
+		NetworkR: libvirtApiClient.NetworkR{
+			Name: m.Name,
+		},
+		CIDR:      m.CIDR.ValueString(),
+		Gateway:   m.Gateway.ValueString(),
+		Domain:    m.Domain.ValueString(),
+		Mode:      m.Mode.ValueString(),
+		Bridge:    m.Bridge.ValueString(),
+		MTU:       m.MTU.ValueInt64(),
+		Autostart: m.Autostart.ValueBool(),
+	}
+	if m.DHCP != nil {
+		bind_payload.DHCP = &networkDHCPPayload{
+			Enabled:   m.DHCP.Enabled.ValueBool(),
+			Start:     m.DHCP.Start.ValueString(),
+			End:       m.DHCP.End.ValueString(),
+			LeaseTime: m.DHCP.LeaseTime.ValueInt64(),
+		}
+	}
+	return bind_payload
+}
+
+// allocated fills what the server picked when the configuration left it open.
+func (m *networkResourceModel) allocated(network *networkPayload) {
+	if m.Gateway.IsUnknown() {
+		m.Gateway = optionalString(network.Gateway)
+	}
+	if m.Bridge.IsUnknown() {
+		m.Bridge = optionalString(network.Bridge)
+	}
+}
+
+// refresh copies what the server knows about the network into the model.
+func (m *networkResourceModel) refresh(network *networkPayload) {
+	m.ID = types.Int64Value(int64(network.ID))
+	m.Name = network.Name
+	m.Status = types.StringValue(networkStatus(network.Status))
+	m.Active = types.BoolValue(network.Status == networkStatusActive)
+	m.CIDR = optionalString(network.CIDR)
+	m.Gateway = optionalString(network.Gateway)
+	m.Domain = optionalString(network.Domain)
+	m.Mode = types.StringValue(valueOrDefault(network.Mode, defaultNetworkMode))
+	m.Bridge = optionalString(network.Bridge)
+	m.MTU = optionalInt64(network.MTU)
+	m.Autostart = types.BoolValue(network.Autostart)
+	m.DHCP = nil
+	if network.DHCP != nil {
+		m.DHCP = &networkDHCP{
+			Enabled:   types.BoolValue(network.DHCP.Enabled),
+			Start:     optionalString(network.DHCP.Start),
+			End:       optionalString(network.DHCP.End),
+			LeaseTime: optionalInt64(network.DHCP.LeaseTime),
+		}
+	}
+}
+
+// networkDefinitionValidator checks that the addresses of a network fit its
+// cidr and that a bridge network names its bridge.
+type networkDefinitionValidator struct{}
+
+func (v networkDefinitionValidator) Description(_ context.Context) string {
+	return "gateway and dhcp addresses must be within cidr, mode bridge requires bridge"
+}
+
+func (v networkDefinitionValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v networkDefinitionValidator) ValidateResource(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var cidr, gateway, mode, bridge types.String
+	var dhcp *networkDHCP
+
+	for name, target := range map[string]interface{}{
+		"cidr":    &cidr,
+		"gateway": &gateway,
+		"mode":    &mode,
+		"bridge":  &bridge,
+		"dhcp":    &dhcp,
+	} {
+		diags := req.Config.GetAttribute(ctx, path.Root(name), target)
+		resp.Diagnostics.Append(diags...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if mode.ValueString() == "bridge" && bridge.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("bridge"), "Missing Attribute", "mode bridge requires the bridge of the host to attach to")
+	}
+
+	if cidr.IsUnknown() {
+		return
+	}
+	if cidr.IsNull() {
+		if !gateway.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("gateway"), "Missing Attribute", "gateway requires cidr")
+		}
+		if dhcp != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("dhcp"), "Missing Attribute", "dhcp requires cidr")
+		}
+		return
+	}
+	// Checked by the cidr validator already.
+	_, network, err := net.ParseCIDR(cidr.ValueString())
+	if err != nil {
+		return
+	}
+
+	addresses := map[string]types.String{
+		"gateway": gateway,
+	}
+	attributes := map[string]path.Path{
+		"gateway": path.Root("gateway"),
+	}
+	if dhcp != nil {
+		addresses["dhcp start"], attributes["dhcp start"] = dhcp.Start, path.Root("dhcp").AtName("start")
+		addresses["dhcp end"], attributes["dhcp end"] = dhcp.End, path.Root("dhcp").AtName("end")
+	}
+	for _, name := range sortedKeys(addresses) {
+		address := addresses[name]
+		if address.IsNull() || address.IsUnknown() {
+			continue
+		}
+		ip := net.ParseIP(address.ValueString())
+		if ip != nil && !network.Contains(ip) {
+			resp.Diagnostics.AddAttributeError(
+				attributes[name],
+				"Invalid Attribute Value",
+				fmt.Sprintf("%s must be within cidr %s, got: %s", name, cidr.ValueString(), address.ValueString()),
+			)
+		}
+	}
+
+	if dhcp == nil || dhcp.Start.IsNull() || dhcp.Start.IsUnknown() || dhcp.End.IsNull() || dhcp.End.IsUnknown() {
+		return
+	}
+	start := net.ParseIP(dhcp.Start.ValueString())
+	end := net.ParseIP(dhcp.End.ValueString())
+	if start != nil && end != nil && bytes.Compare(start.To16(), end.To16()) > 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("dhcp").AtName("end"),
+			"Invalid Attribute Value",
+			fmt.Sprintf("dhcp end must not be before start %s, got: %s", dhcp.Start.ValueString(), dhcp.End.ValueString()),
+		)
+	}
+}
@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// UpgradeState migrates states written by older versions of the provider.
+func (r *networkResource) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		// Version 0 states come with and without the network definition, so
+		// the raw state is rewritten instead of going through a prior schema.
+		0: {
+			StateUpgrader: upgradeNetworkStateV0,
+		},
+	}
+}
+
+// upgradeNetworkStateV0 turns the numeric status into its name.
+func upgradeNetworkStateV0(_ context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var state map[string]interface{}
+	err := json.Unmarshal(req.RawState.JSON, &state)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Network State", err.Error())
+		return
+	}
+
+	if status, ok := state["status"].(float64); ok {
+		state["status"] = networkStatus(int(status))
+	}
+
+	raw, err := json.Marshal(state)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Network State", err.Error())
+		return
+	}
+	resp.DynamicValue = &tfprotov6.DynamicValue{JSON: raw}
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+func TestUpgradeNetworkStateV0(t *testing.T) {
+	cases := []struct {
+		name       string
+		raw        string
+		wantStatus string
+		wantCIDR   string
+	}{
+		{
+			name:       "active",
+			raw:        `{"id":3,"name":"lan","status":1}`,
+			wantStatus: "active",
+		},
+		{
+			name:       "inactive",
+			raw:        `{"id":3,"name":"lan","status":0}`,
+			wantStatus: "inactive",
+		},
+		{
+			name:       "unknown status",
+			raw:        `{"id":3,"name":"lan","status":7}`,
+			wantStatus: "unknown",
+		},
+		{
+			name: "without status",
+			raw:  `{"id":3,"name":"lan"}`,
+		},
+		{
+			name:       "with definition",
+			raw:        `{"id":3,"name":"lan","status":1,"cidr":"10.0.0.0/24","gateway":"10.0.0.1","mode":"nat","autostart":true}`,
+			wantStatus: "active",
+			wantCIDR:   "10.0.0.0/24",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state, diags := upgradeTestState(t, NewNetworkResource(), "libvirtapi_network", c.raw)
+			if diags != nil {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			var got networkResourceModel
+			if d := state.Get(context.Background(), &got); d.HasError() {
+				t.Fatalf("reading the upgraded state: %v", d)
+			}
+
+			if got.ID.ValueInt64() != 3 || got.Name != "lan" {
+				t.Errorf("got id %s, name %s", got.ID, got.Name)
+			}
+			if got.Status.ValueString() != c.wantStatus {
+				t.Errorf("got status %s, want %q", got.Status, c.wantStatus)
+			}
+			if got.CIDR.ValueString() != c.wantCIDR {
+				t.Errorf("got cidr %s, want %q", got.CIDR, c.wantCIDR)
+			}
+		})
+	}
+}
+
+func TestUpgradeNetworkStateV0Invalid(t *testing.T) {
+	_, diags := upgradeTestState(t, NewNetworkResource(), "libvirtapi_network", `{"id":3,`)
+	if len(diags) == 0 || diags[0].Severity != tfprotov6.DiagnosticSeverityError {
+		t.Fatalf("got diagnostics %v, want an error", diags)
+	}
+	if diags[0].Summary != "Unable to Upgrade Network State" {
+		t.Errorf("got %q", diags[0].Summary)
+	}
+}
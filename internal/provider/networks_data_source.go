@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type networksDataSource struct {
+	client *libvirtApiClient.Client
+}
+
+type networksDataSourceModel struct {
+	NameRegex types.String             `tfsdk:"name_regex"`
+	Status    types.String             `tfsdk:"status"`
+	Mode      types.String             `tfsdk:"mode"`
+	Networks  []networkDataSourceModel `tfsdk:"networks"`
+}
+
+var (
+	_ datasource.DataSource              = &networksDataSource{}
+	_ datasource.DataSourceWithConfigure = &networksDataSource{}
+)
+
+func NewNetworksDataSource() datasource.DataSource {
+	return &networksDataSource{}
+}
+
+func (d *networksDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*libvirtApiClient.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *libvirtApiClient.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *networksDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_networks"
+}
+
+func (d *networksDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"name_regex": schema.StringAttribute{
+				Description: "Only return networks whose name matches this regular expression.",
+				Optional:    true,
+			},
+			"status": schema.StringAttribute{
+				Description: "Only return networks in this status.",
+				Optional:    true,
+
+				Validators: []validator.String{
+					stringOneOf("active", "inactive", "unknown"),
+				},
+			},
+			"mode": schema.StringAttribute{
+				Description: "Only return networks with this forward mode.",
+				Optional:    true,
+
+				Validators: []validator.String{
+					stringOneOf("nat", "route", "bridge", "isolated", "open"),
+				},
+			},
+			"networks": schema.ListNestedAttribute{
+				Computed: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: networkComputedAttributes(),
+				},
+			},
+		},
+	}
+}
+
+func (d *networksDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data networksDataSourceModel
+
+	diags := req.Config.Get(ctx, &data)
+
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var nameRegex *regexp.Regexp
+	if !data.NameRegex.IsNull() {
+		var err error
+		nameRegex, err = regexp.Compile(data.NameRegex.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("name_regex"), "Invalid Attribute Value", err.Error())
+			return
+		}
+	}
+
+	networks, err := listNetworks(ctx, d.client)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Read networks",
+			err.Error(),
+		)
+		return
+	}
+
+	data.Networks = []networkDataSourceModel{}
+	for i := range networks {
+		if !data.matches(&networks[i], nameRegex) {
+			continue
+		}
+		var network networkDataSourceModel
+		network.refresh(&networks[i])
+		data.Networks = append(data.Networks, network)
+	}
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// matches reports whether network passes every filter that is set.
+func (m *networksDataSourceModel) matches(network *networkPayload, nameRegex *regexp.Regexp) bool {
+	if nameRegex != nil && !nameRegex.MatchString(network.Name) {
+		return false
+	}
+	if !m.Status.IsNull() && networkStatus(network.Status) != m.Status.ValueString() {
+		return false
+	}
+	if !m.Mode.IsNull() && valueOrDefault(network.Mode, defaultNetworkMode) != m.Mode.ValueString() {
+		return false
+	}
+	return true
+}
@@ -2,9 +2,11 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
-	"time"
+	"strconv"
+	"strings"
 
 	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
@@ -24,10 +26,46 @@ type libvirtapiProvider struct {
 	version string
 }
 
+// libvirtapiResourceData is handed to resources by Configure, next to the
+// client it carries provider wide settings.
+type libvirtapiResourceData struct {
+	client *libvirtApiClient.Client
+
+	// nodePorts is the nodeport_range, unset means any port.
+	nodePorts *nodePortRange
+}
+
+type nodePortRange struct {
+	min int64
+	max int64
+}
+
+func (r nodePortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.min, r.max)
+}
+
 type libvirtapiProviderModel struct {
-	Hostname types.String `tfsdk:"hostname"`
+	Hostname       types.String `tfsdk:"hostname"`
+	Endpoints      types.List   `tfsdk:"endpoints"`
+	FailoverPolicy types.String `tfsdk:"failover_policy"`
+
 	Username types.String `tfsdk:"username"`
 	Password types.String `tfsdk:"password"`
+
+	Token             types.String `tfsdk:"token"`
+	CredentialProcess types.String `tfsdk:"credential_process"`
+
+	CACertFile         types.String `tfsdk:"ca_cert_file"`
+	ClientCertFile     types.String `tfsdk:"client_cert_file"`
+	ClientKeyFile      types.String `tfsdk:"client_key_file"`
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+
+	MaxRetries     types.Int64  `tfsdk:"max_retries"`
+	RetryWaitMin   types.String `tfsdk:"retry_wait_min"`
+	RetryWaitMax   types.String `tfsdk:"retry_wait_max"`
+	RequestTimeout types.String `tfsdk:"request_timeout"`
+
+	NodePortRange types.String `tfsdk:"nodeport_range"`
 }
 
 func New(version string) func() provider.Provider {
@@ -49,11 +87,66 @@ func (p *libvirtapiProvider) Schema(_ context.Context, _ provider.SchemaRequest,
 			"hostname": schema.StringAttribute{
 				Optional: true,
 			},
+			"endpoints": schema.ListAttribute{
+				Description: "Ordered libvirtApi URLs, used instead of hostname. Can also be set with LIBVIRTapi_ENDPOINTS as a comma separated list.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"failover_policy": schema.StringAttribute{
+				Description: "How endpoints are picked: first_healthy (default) sends every request to the first reachable endpoint, round_robin spreads reads over all of them. Can also be set with LIBVIRTapi_FAILOVER_POLICY.",
+				Optional:    true,
+			},
 			"username": schema.StringAttribute{
 				Optional: true,
 			},
 			"password": schema.StringAttribute{
-				Optional: true,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"token": schema.StringAttribute{
+				Description: "Bearer token used instead of username and password. Can also be set with LIBVIRTapi_TOKEN.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"credential_process": schema.StringAttribute{
+				Description: "Command printing JSON credentials {\"token\": ..., \"expiration\": ...}, run again when the token expires. Can also be set with LIBVIRTapi_CREDENTIAL_PROCESS.",
+				Optional:    true,
+			},
+			"ca_cert_file": schema.StringAttribute{
+				Description: "PEM file with the CA used to verify the libvirtApi server. Can also be set with LIBVIRTapi_CA_CERT_FILE.",
+				Optional:    true,
+			},
+			"client_cert_file": schema.StringAttribute{
+				Description: "PEM client certificate for mutual TLS. Can also be set with LIBVIRTapi_CLIENT_CERT_FILE.",
+				Optional:    true,
+			},
+			"client_key_file": schema.StringAttribute{
+				Description: "PEM key of client_cert_file. Can also be set with LIBVIRTapi_CLIENT_KEY_FILE.",
+				Optional:    true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				Description: "Skip verification of the server certificate. Can also be set with LIBVIRTapi_INSECURE_SKIP_VERIFY.",
+				Optional:    true,
+			},
+			"max_retries": schema.Int64Attribute{
+				Description: "How many times a failed request is retried, defaults to 4. Can also be set with LIBVIRTapi_MAX_RETRIES.",
+				Optional:    true,
+			},
+			"retry_wait_min": schema.StringAttribute{
+				Description: "Wait before the first retry, doubled on every attempt, defaults to 1s. Can also be set with LIBVIRTapi_RETRY_WAIT_MIN.",
+				Optional:    true,
+			},
+			"retry_wait_max": schema.StringAttribute{
+				Description: "Upper bound of the wait between retries, defaults to 30s. Can also be set with LIBVIRTapi_RETRY_WAIT_MAX.",
+				Optional:    true,
+			},
+			"request_timeout": schema.StringAttribute{
+				Description: "Timeout of a single request attempt, defaults to 10s. Can also be set with LIBVIRTapi_REQUEST_TIMEOUT.",
+				Optional:    true,
+			},
+			"nodeport_range": schema.StringAttribute{
+				Description: "Range load balancer nodeports must be taken from, e.g. 30000-32767. The server assigns omitted nodeports from it, or from its own range when it is not set. Can also be set with LIBVIRTapi_NODEPORT_RANGE.",
+				Optional:    true,
 			},
 		},
 	}
@@ -72,38 +165,197 @@ func (p *libvirtapiProvider) Configure(ctx context.Context, req provider.Configu
 	if config.Hostname.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(path.Root("hostname"), "Unknown libvirtapi hostname", "...")
 	}
+	if config.Endpoints.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("endpoints"), "Unknown libvirtapi endpoints", "...")
+	}
+	if config.FailoverPolicy.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("failover_policy"), "Unknown libvirtapi failover_policy", "...")
+	}
 	if config.Username.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(path.Root("Username"), "Unknown libvirtapi username", "...")
 	}
 	if config.Password.IsUnknown() {
 		resp.Diagnostics.AddAttributeError(path.Root("password"), "Unknown libvirtapi password", "...")
 	}
+	if config.Token.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("token"), "Unknown libvirtapi token", "...")
+	}
+	if config.CredentialProcess.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("credential_process"), "Unknown libvirtapi credential_process", "...")
+	}
+	if config.CACertFile.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("ca_cert_file"), "Unknown libvirtapi CA certificate file", "...")
+	}
+	if config.ClientCertFile.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("client_cert_file"), "Unknown libvirtapi client certificate file", "...")
+	}
+	if config.ClientKeyFile.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("client_key_file"), "Unknown libvirtapi client key file", "...")
+	}
+	if config.InsecureSkipVerify.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("insecure_skip_verify"), "Unknown libvirtapi insecure_skip_verify", "...")
+	}
+
+	if config.MaxRetries.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("max_retries"), "Unknown libvirtapi max_retries", "...")
+	}
+	if config.RetryWaitMin.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("retry_wait_min"), "Unknown libvirtapi retry_wait_min", "...")
+	}
+	if config.RetryWaitMax.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("retry_wait_max"), "Unknown libvirtapi retry_wait_max", "...")
+	}
+	if config.RequestTimeout.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("request_timeout"), "Unknown libvirtapi request_timeout", "...")
+	}
+
+	if config.NodePortRange.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(path.Root("nodeport_range"), "Unknown libvirtapi nodeport_range", "...")
+	}
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	hostname := os.Getenv("LIBVIRTapi_HOST")
+	var endpoints []string
+	if value := os.Getenv("LIBVIRTapi_ENDPOINTS"); value != "" {
+		endpoints = strings.Split(value, ",")
+	}
+	failoverPolicy := envOrDefault("LIBVIRTapi_FAILOVER_POLICY", failoverFirstHealthy)
 	username := os.Getenv("LIBVIRTapi_USERNAME")
 	password := os.Getenv("LIBVIRTapi_PASSWORD")
+	token := os.Getenv("LIBVIRTapi_TOKEN")
+	credentialCommand := os.Getenv("LIBVIRTapi_CREDENTIAL_PROCESS")
+	caCertFile := os.Getenv("LIBVIRTapi_CA_CERT_FILE")
+	clientCertFile := os.Getenv("LIBVIRTapi_CLIENT_CERT_FILE")
+	clientKeyFile := os.Getenv("LIBVIRTapi_CLIENT_KEY_FILE")
+	insecureSkipVerify := false
+	maxRetries := int64(4)
+	retryWaitMin := envOrDefault("LIBVIRTapi_RETRY_WAIT_MIN", "1s")
+	retryWaitMax := envOrDefault("LIBVIRTapi_RETRY_WAIT_MAX", "30s")
+	requestTimeout := envOrDefault("LIBVIRTapi_REQUEST_TIMEOUT", "10s")
+	nodePortSetting := os.Getenv("LIBVIRTapi_NODEPORT_RANGE")
+
+	if value := os.Getenv("LIBVIRTapi_INSECURE_SKIP_VERIFY"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("insecure_skip_verify"), "Invalid LIBVIRTapi_INSECURE_SKIP_VERIFY", err.Error())
+			return
+		}
+		insecureSkipVerify = parsed
+	}
+	if value := os.Getenv("LIBVIRTapi_MAX_RETRIES"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("max_retries"), "Invalid LIBVIRTapi_MAX_RETRIES", err.Error())
+			return
+		}
+		maxRetries = parsed
+	}
 
 	if !config.Hostname.IsNull() {
 		hostname = config.Hostname.ValueString()
 	}
+	if !config.Endpoints.IsNull() {
+		endpoints = nil
+		resp.Diagnostics.Append(config.Endpoints.ElementsAs(ctx, &endpoints, false)...)
+	}
+	if !config.FailoverPolicy.IsNull() {
+		failoverPolicy = config.FailoverPolicy.ValueString()
+	}
+	if !config.Hostname.IsNull() && !config.Endpoints.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("endpoints"), "Conflicting libvirtapi endpoints", "Only one of hostname and endpoints can be set.")
+	}
+	if len(endpoints) > 0 {
+		for i := range endpoints {
+			endpoints[i] = strings.TrimSpace(endpoints[i])
+		}
+		hostname = strings.TrimSuffix(endpoints[0], "/")
+	} else if hostname != "" {
+		endpoints = []string{hostname}
+	}
 	if !config.Username.IsNull() {
 		username = config.Username.ValueString()
 	}
 	if !config.Password.IsNull() {
 		password = config.Password.ValueString()
 	}
+	if !config.Token.IsNull() {
+		token = config.Token.ValueString()
+	}
+	if !config.CredentialProcess.IsNull() {
+		credentialCommand = config.CredentialProcess.ValueString()
+	}
+	if !config.CACertFile.IsNull() {
+		caCertFile = config.CACertFile.ValueString()
+	}
+	if !config.ClientCertFile.IsNull() {
+		clientCertFile = config.ClientCertFile.ValueString()
+	}
+	if !config.ClientKeyFile.IsNull() {
+		clientKeyFile = config.ClientKeyFile.ValueString()
+	}
+	if !config.InsecureSkipVerify.IsNull() {
+		insecureSkipVerify = config.InsecureSkipVerify.ValueBool()
+	}
+	if !config.MaxRetries.IsNull() {
+		maxRetries = config.MaxRetries.ValueInt64()
+	}
+	if !config.RetryWaitMin.IsNull() {
+		retryWaitMin = config.RetryWaitMin.ValueString()
+	}
+	if !config.RetryWaitMax.IsNull() {
+		retryWaitMax = config.RetryWaitMax.ValueString()
+	}
+	if !config.RequestTimeout.IsNull() {
+		requestTimeout = config.RequestTimeout.ValueString()
+	}
+	if !config.NodePortRange.IsNull() {
+		nodePortSetting = config.NodePortRange.ValueString()
+	}
+
+	var nodePorts *nodePortRange
+	if nodePortSetting != "" {
+		var err error
+		nodePorts, err = parseNodePortRange(nodePortSetting)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("nodeport_range"), "Invalid libvirtapi nodeport_range", err.Error())
+		}
+	}
 
+	waitMin, err := parseDurationSetting("retry_wait_min", retryWaitMin)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("retry_wait_min"), "Invalid libvirtapi retry_wait_min", err.Error())
+	}
+	waitMax, err := parseDurationSetting("retry_wait_max", retryWaitMax)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("retry_wait_max"), "Invalid libvirtapi retry_wait_max", err.Error())
+	}
+	timeout, err := parseDurationSetting("request_timeout", requestTimeout)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("request_timeout"), "Invalid libvirtapi request_timeout", err.Error())
+	}
+	if maxRetries < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_retries"), "Invalid libvirtapi max_retries", "max_retries must not be negative")
+	}
+	if waitMin > waitMax {
+		resp.Diagnostics.AddAttributeError(path.Root("retry_wait_min"), "Invalid libvirtapi retry_wait_min", "retry_wait_min must not be greater than retry_wait_max")
+	}
+
+	// A token, a credential process or a client certificate authenticate on
+	// their own, basic auth is only required without them.
+	basicAuth := token == "" && credentialCommand == "" && clientCertFile == ""
 	if hostname == "" {
 		resp.Diagnostics.AddAttributeError(path.Root("hostname"), "Missing livbirtApi Hostname", "...")
 	}
-	if username == "" {
+	if token != "" && credentialCommand != "" {
+		resp.Diagnostics.AddAttributeError(path.Root("token"), "Conflicting libvirtapi credentials", "Only one of token and credential_process can be set.")
+	}
+	if username == "" && basicAuth {
 		resp.Diagnostics.AddAttributeError(path.Root("username"), "Missing libvirtapi API Username", "..")
 	}
-	if password == "" {
+	if password == "" && basicAuth {
 		resp.Diagnostics.AddAttributeError(path.Root("password"), "Missing libvirtapi API Password", "...")
 	}
 
@@ -111,34 +363,110 @@ func (p *libvirtapiProvider) Configure(ctx context.Context, req provider.Configu
 		return
 	}
 	ctx = tflog.SetField(ctx, "libvirtapi_hostname", hostname)
+	ctx = tflog.SetField(ctx, "libvirtapi_endpoints", endpoints)
 	ctx = tflog.SetField(ctx, "libvirtapi_username", username)
 	ctx = tflog.SetField(ctx, "libvirtapi_passowrd", password)
 	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, "libvirtapi_passowrd")
+	if token != "" {
+		ctx = tflog.MaskAllFieldValuesStrings(ctx, token)
+	}
 
 	tflog.Debug(ctx, "Creating libvirtapi Client")
 
-	conf := libvirtApiClient.Config{Username: &username, Password: &password, Url: &hostname}
-	client, err := libvirtApiClient.NewClient(conf, &http.Client{Timeout: 10 * time.Second})
+	tlsConfig, err := newTLSConfig(caCertFile, clientCertFile, clientKeyFile, insecureSkipVerify)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid libvirtapi TLS configuration", err.Error())
+		return
+	}
+
+	var transport http.RoundTripper = newTransport(tlsConfig)
 
+	conf := libvirtApiClient.Config{Url: &hostname}
+	switch {
+	case credentialCommand != "":
+		process, err := newCredentialProcess(credentialCommand)
+		if err == nil {
+			_, err = process.Token(ctx)
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("credential_process"), "Unable to get libvirtapi credentials", err.Error())
+			return
+		}
+		transport = &credentialTransport{process: process, next: transport}
+	case token != "":
+		// Set on the client below, there is nothing to sign in with.
+	case username != "" && password != "":
+		conf.Username = &username
+		conf.Password = &password
+	}
+	failover, err := newFailoverTransport(ctx, transport, endpoints, failoverPolicy)
 	if err != nil {
-		resp.Diagnostics.AddError("Unable to Create libvirtapi Client", "...")
+		resp.Diagnostics.AddAttributeError(path.Root("endpoints"), "Invalid libvirtapi endpoints", err.Error())
 		return
 	}
+	transport = &retryTransport{
+		next:       failover,
+		maxRetries: int(maxRetries),
+		waitMin:    waitMin,
+		waitMax:    waitMax,
+		timeout:    timeout,
+		logCtx:     ctx,
+	}
+	client, err := libvirtApiClient.NewClient(conf, &http.Client{Transport: transport})
+
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to Create libvirtapi Client", err.Error())
+		return
+	}
+	if token != "" {
+		client.Token = token
+	}
 
 	resp.DataSourceData = client
-	resp.ResourceData = client
+	resp.ResourceData = &libvirtapiResourceData{
+		client:    client,
+		nodePorts: nodePorts,
+	}
 
 	tflog.Info(ctx, "Configured Libvirtapi client")
 }
 
+// parseNodePortRange parses a range such as "30000-32767".
+func parseNodePortRange(value string) (*nodePortRange, error) {
+	first, last, found := strings.Cut(value, "-")
+	if !found {
+		return nil, fmt.Errorf("expected a range of the form min-max, got %q", value)
+	}
+	min, err := strconv.ParseInt(strings.TrimSpace(first), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	max, err := strconv.ParseInt(strings.TrimSpace(last), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if min < 1 || max > 65535 || min > max {
+		return nil, fmt.Errorf("range %q must be within 1-65535 and min must not be greater than max", value)
+	}
+	return &nodePortRange{min: min, max: max}, nil
+}
+
+// envOrDefault returns the environment variable key, or fallback when it is not set.
+func envOrDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
 func (p *libvirtapiProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
-		NewNetworkDataSource,
+		NewNetworkDataSource, NewNetworksDataSource, NewLoadbalancerDataSource, NewLoadbalancersDataSource,
 	}
 }
 
 func (p *libvirtapiProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
-		NewNetworkResource, NewLoadbalancerResource,
+		NewNetworkResource, NewNetworkDHCPHostResource, NewLoadbalancerResource, NewVmResource, NewCertificateResource,
 	}
 }
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// retryTransport retries requests that failed because of the server being
+// unavailable, e.g. while libvirtApi restarts during an upgrade. Every attempt
+// gets its own timeout.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	waitMin    time.Duration
+	waitMax    time.Duration
+	timeout    time.Duration
+
+	// logCtx carries the provider logger, requests made by libvirtApiClient
+	// only have a background context.
+	logCtx context.Context
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := t.attempt(req)
+
+		if attempt >= t.maxRetries || !t.shouldRetry(req, response, err) {
+			return response, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return response, err
+		}
+
+		wait := t.backoff(attempt, response)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = response.Status
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		tflog.Warn(t.logCtx, "Retrying libvirtapi request", map[string]interface{}{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+			"reason":  reason,
+		})
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// attempt sends req once, bounded by the per-request timeout.
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	response, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+func (t *retryTransport) shouldRetry(req *http.Request, response *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	// The server did not process a throttled request, whatever the method.
+	if err == nil && response.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if !idempotent(req.Method) {
+		return false
+	}
+
+	return err != nil || response.StatusCode >= 500
+}
+
+// idempotent reports whether a request with method can be sent twice without
+// changing the outcome.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff doubles the wait for every attempt, unless the server says otherwise.
+func (t *retryTransport) backoff(attempt int, response *http.Response) time.Duration {
+	if response != nil {
+		if wait, ok := retryAfter(response.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+
+	// Past 62 doublings the shift overflows, to zero for attempts of 64 and more.
+	wait := t.waitMin << attempt
+	if attempt > 62 || wait > t.waitMax || wait <= 0 {
+		wait = t.waitMax
+	}
+	return wait
+}
+
+// retryAfter parses a Retry-After header, either seconds or an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// cancelBody releases the per-attempt context once the body has been read.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// parseDurationSetting reads a duration attribute such as "10s".
+func parseDurationSetting(name string, value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%s must not be negative", name)
+	}
+	return duration, nil
+}
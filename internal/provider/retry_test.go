@@ -0,0 +1,228 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestRetryTransport() *retryTransport {
+	return &retryTransport{
+		next:       http.DefaultTransport,
+		maxRetries: 2,
+		waitMin:    time.Millisecond,
+		waitMax:    time.Millisecond,
+		logCtx:     context.Background(),
+	}
+}
+
+func TestRetryTransportStatus(t *testing.T) {
+	cases := []struct {
+		name     string
+		method   string
+		status   int
+		attempts int32
+	}{
+		{"get ok", http.MethodGet, http.StatusOK, 1},
+		{"get not found", http.MethodGet, http.StatusNotFound, 1},
+		{"get server error", http.MethodGet, http.StatusInternalServerError, 3},
+		{"put unavailable", http.MethodPut, http.StatusServiceUnavailable, 3},
+		{"delete bad gateway", http.MethodDelete, http.StatusBadGateway, 3},
+		{"post server error", http.MethodPost, http.StatusInternalServerError, 1},
+		{"get throttled", http.MethodGet, http.StatusTooManyRequests, 3},
+		{"post throttled", http.MethodPost, http.StatusTooManyRequests, 3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(c.status)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: newTestRetryTransport()}
+			request, err := http.NewRequest(c.method, server.URL, strings.NewReader("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := client.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response.Body.Close()
+
+			if response.StatusCode != c.status {
+				t.Errorf("status: got %d, want %d", response.StatusCode, c.status)
+			}
+			if got := atomic.LoadInt32(&attempts); got != c.attempts {
+				t.Errorf("attempts: got %d, want %d", got, c.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransportConnectionError(t *testing.T) {
+	cases := []struct {
+		method   string
+		attempts int32
+	}{
+		{http.MethodGet, 3},
+		{http.MethodPut, 3},
+		{http.MethodDelete, 3},
+		{http.MethodPost, 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method, func(t *testing.T) {
+			// The server gets the request and drops the connection without
+			// an answer.
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: newTestRetryTransport()}
+			request, err := http.NewRequest(c.method, server.URL, strings.NewReader("{}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = client.Do(request)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := atomic.LoadInt32(&attempts); got != c.attempts {
+				t.Errorf("attempts: got %d, want %d", got, c.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransportReplaysBody(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newTestRetryTransport()}
+	request, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"name":"db"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 || bodies[0] != `{"name":"db"}` || bodies[1] != `{"name":"db"}` {
+		t.Errorf("bodies: got %q", bodies)
+	}
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	transport := &retryTransport{waitMin: time.Second, waitMax: 5 * time.Second}
+
+	cases := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		want       time.Duration
+	}{
+		{"first", 0, "", time.Second},
+		{"doubles", 2, "", 4 * time.Second},
+		{"capped", 3, "", 5 * time.Second},
+		{"overflow", 80, "", 5 * time.Second},
+		{"retry after seconds", 3, "7", 7 * time.Second},
+		{"retry after invalid", 0, "soon", time.Second},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			response := &http.Response{Header: http.Header{}}
+			if c.retryAfter != "" {
+				response.Header.Set("Retry-After", c.retryAfter)
+			}
+			if got := transport.backoff(c.attempt, response); got != c.want {
+				t.Errorf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		value string
+		ok    bool
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", false, 0, 0},
+		{"0", true, 0, 0},
+		{"120", true, 120 * time.Second, 120 * time.Second},
+		{"-1", false, 0, 0},
+		{"later", false, 0, 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), true, 58 * time.Minute, time.Hour},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), true, 0, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			got, ok := retryAfter(c.value)
+			if ok != c.ok {
+				t.Fatalf("ok: got %v, want %v", ok, c.ok)
+			}
+			if got < c.min || got > c.max {
+				t.Errorf("got %s, want between %s and %s", got, c.min, c.max)
+			}
+		})
+	}
+}
+
+func TestRetryTransportHonoursRetryAfter(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newTestRetryTransport()}
+	start := time.Now()
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if got := atomic.LoadInt32(&attempts); response.StatusCode != http.StatusOK || got != 2 {
+		t.Errorf("got status %d after %d attempts", response.StatusCode, got)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, before Retry-After", waited)
+	}
+}
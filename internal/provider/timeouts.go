@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+const defaultTimeout = 10 * time.Minute
+
+// pollInterval is how often waitFor asks the server again.
+var pollInterval = 2 * time.Second
+
+// resourceTimeouts is the timeouts block of the resources that wait for the
+// server to finish provisioning.
+type resourceTimeouts struct {
+	Create basetypes.StringValue `tfsdk:"create"`
+	Update basetypes.StringValue `tfsdk:"update"`
+	Delete basetypes.StringValue `tfsdk:"delete"`
+}
+
+// timeoutsBlock is a block rather than an attribute, so it is written like
+// the timeouts of other providers: timeouts { create = "5m" }.
+func timeoutsBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Description: "How long to wait for the server, as a duration like 30s or 5m. Defaults to 10m.",
+
+		Attributes: map[string]schema.Attribute{
+			"create": timeoutAttribute(),
+			"update": timeoutAttribute(),
+			"delete": timeoutAttribute(),
+		},
+	}
+}
+
+func timeoutAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Optional: true,
+
+		Validators: []validator.String{
+			duration(),
+		},
+	}
+}
+
+func (t *resourceTimeouts) create() time.Duration {
+	if t == nil {
+		return defaultTimeout
+	}
+	return timeoutOrDefault(t.Create)
+}
+
+func (t *resourceTimeouts) update() time.Duration {
+	if t == nil {
+		return defaultTimeout
+	}
+	return timeoutOrDefault(t.Update)
+}
+
+func (t *resourceTimeouts) delete() time.Duration {
+	if t == nil {
+		return defaultTimeout
+	}
+	return timeoutOrDefault(t.Delete)
+}
+
+// timeoutOrDefault reads a duration checked by the duration validator.
+func timeoutOrDefault(value basetypes.StringValue) time.Duration {
+	if value.IsNull() || value.IsUnknown() {
+		return defaultTimeout
+	}
+	timeout, err := time.ParseDuration(value.ValueString())
+	if err != nil {
+		return defaultTimeout
+	}
+	return timeout
+}
+
+// waitFor calls done until it reports true, fails, or timeout runs out.
+func waitFor(ctx context.Context, timeout time.Duration, done func(ctx context.Context) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		ok, err := done(ctx)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %s: %w", timeout, err)
+			}
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %s", timeout)
+			}
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ validator.String = stringOneOfValidator{}
+	_ validator.String = ipAddressValidator{}
+	_ validator.String = durationValidator{}
+	_ validator.String = cidrValidator{}
+	_ validator.String = stringMatchesValidator{}
+	_ validator.String = macAddressValidator{}
+	_ validator.Int64  = int64BetweenValidator{}
+	_ validator.List   = cidrListValidator{}
+)
+
+// stringOneOf checks that a string is one of values.
+func stringOneOf(values ...string) validator.String {
+	return stringOneOfValidator{values: values}
+}
+
+type stringOneOfValidator struct {
+	values []string
+}
+
+func (v stringOneOfValidator) Description(_ context.Context) string {
+	return "value must be one of: " + strings.Join(v.values, ", ")
+}
+
+func (v stringOneOfValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v stringOneOfValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	for _, allowed := range v.values {
+		if value == allowed {
+			return
+		}
+	}
+	resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("%s, got: %q", v.Description(ctx), value))
+}
+
+// int64Between checks that a number is within [min, max].
+func int64Between(min int64, max int64) validator.Int64 {
+	return int64BetweenValidator{min: min, max: max}
+}
+
+type int64BetweenValidator struct {
+	min int64
+	max int64
+}
+
+func (v int64BetweenValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("value must be between %d and %d", v.min, v.max)
+}
+
+func (v int64BetweenValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v int64BetweenValidator) ValidateInt64(ctx context.Context, req validator.Int64Request, resp *validator.Int64Response) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueInt64()
+	if value < v.min || value > v.max {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("%s, got: %d", v.Description(ctx), value))
+	}
+}
+
+// ipAddress checks that a string is an IPv4 or IPv6 address.
+func ipAddress() validator.String {
+	return ipAddressValidator{}
+}
+
+type ipAddressValidator struct{}
+
+func (v ipAddressValidator) Description(_ context.Context) string {
+	return "value must be an IPv4 or IPv6 address"
+}
+
+func (v ipAddressValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v ipAddressValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	if net.ParseIP(value) == nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("%s, got: %q", v.Description(ctx), value))
+	}
+}
+
+// duration checks that a string is a positive duration like 30s or 5m.
+func duration() validator.String {
+	return durationValidator{}
+}
+
+type durationValidator struct{}
+
+func (v durationValidator) Description(_ context.Context) string {
+	return "value must be a positive duration like 30s or 5m"
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("%s, got: %q", v.Description(ctx), value))
+	}
+}
+
+// cidrList checks that every string of a list is an IPv4 or IPv6 CIDR.
+func cidrList() validator.List {
+	return cidrListValidator{}
+}
+
+type cidrListValidator struct{}
+
+func (v cidrListValidator) Description(_ context.Context) string {
+	return "values must be CIDRs like 10.0.0.0/8"
+}
+
+func (v cidrListValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v cidrListValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for i, element := range req.ConfigValue.Elements() {
+		value, ok := element.(types.String)
+		if !ok || value.IsNull() || value.IsUnknown() {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(value.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid Attribute Value", fmt.Sprintf("%s, got: %q", v.Description(ctx), value.ValueString()))
+		}
+	}
+}
+
+// cidr checks that a string is an IPv4 or IPv6 CIDR.
+func cidr() validator.String {
+	return cidrValidator{}
+}
+
+type cidrValidator struct{}
+
+func (v cidrValidator) Description(_ context.Context) string {
+	return "value must be a CIDR like 10.0.0.0/24"
+}
+
+func (v cidrValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v cidrValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("%s, got: %q", v.Description(ctx), value))
+	}
+}
+
+// stringMatches checks that a string matches re, description tells the user
+// what re expects.
+func stringMatches(re *regexp.Regexp, description string) validator.String {
+	return stringMatchesValidator{re: re, description: description}
+}
+
+type stringMatchesValidator struct {
+	re          *regexp.Regexp
+	description string
+}
+
+func (v stringMatchesValidator) Description(_ context.Context) string {
+	return "value must be " + v.description
+}
+
+func (v stringMatchesValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v stringMatchesValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	if !v.re.MatchString(value) {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("%s, got: %q", v.Description(ctx), value))
+	}
+}
+
+// macAddress checks that a string is a MAC address like 52:54:00:12:34:56.
+func macAddress() validator.String {
+	return macAddressValidator{}
+}
+
+type macAddressValidator struct{}
+
+func (v macAddressValidator) Description(_ context.Context) string {
+	return "value must be a lower case MAC address like 52:54:00:12:34:56"
+}
+
+func (v macAddressValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v macAddressValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	// Only the form libvirt reports back, so the state does not drift.
+	value := req.ConfigValue.ValueString()
+	mac, err := net.ParseMAC(value)
+	if err != nil || len(mac) != 6 || mac.String() != value {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Attribute Value", fmt.Sprintf("%s, got: %q", v.Description(ctx), value))
+	}
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+)
+
+// The functions below mirror the disk calls of libvirtApiClient, on the same
+// endpoints, but report the HTTP status of the server as an apiError.
+
+func getVm(ctx context.Context, client *libvirtApiClient.Client, name string) (*vmPayload, bool, error) {
+	var vm vmPayload
+	err := apiRequest(ctx, client, http.MethodGet, "/api/v2/node/"+url.PathEscape(name), nil, &vm)
+	if isNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &vm, true, nil
+}
+
+func createDisk(ctx context.Context, client *libvirtApiClient.Client, size int) (*libvirtApiClient.Disk, error) {
+	var disk libvirtApiClient.Disk
+	err := apiRequest(ctx, client, http.MethodPost, "/api/v2/hdd", map[string]string{
+		"size": strconv.Itoa(size),
+		"path": "/var/lib/libvirt/images",
+	}, &disk)
+	if err != nil {
+		return nil, err
+	}
+	if disk.ID == 0 {
+		return nil, fmt.Errorf("libvirtApi returned no id for the new disk")
+	}
+	return &disk, nil
+}
+
+func deleteDisk(ctx context.Context, client *libvirtApiClient.Client, id int) error {
+	return apiRequest(ctx, client, http.MethodDelete, fmt.Sprintf("/api/v2/hdd/%d", id), nil, nil)
+}
+
+func bindDisk(ctx context.Context, client *libvirtApiClient.Client, id int, name string) error {
+	return apiRequest(ctx, client, http.MethodPut, fmt.Sprintf("/api/v2/node/%s/hdd/%d", url.PathEscape(name), id), nil, nil)
+}
+
+func unbindDisk(ctx context.Context, client *libvirtApiClient.Client, id int, name string) error {
+	return apiRequest(ctx, client, http.MethodDelete, fmt.Sprintf("/api/v2/node/%s/hdd/%d", url.PathEscape(name), id), nil, nil)
+}
@@ -0,0 +1,421 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	libvirtApiClient "github.com/goryszewski/libvirtApi-client/libvirtApiClient"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ resource.Resource                = &vmResource{}
+	_ resource.ResourceWithConfigure   = &vmResource{}
+	_ resource.ResourceWithImportState = &vmResource{}
+)
+
+// NewVmResource is a helper function to simplify the provider implementation.
+func NewVmResource() resource.Resource {
+	return &vmResource{}
+}
+
+// vmResource is the resource implementation.
+type vmResource struct {
+	client *libvirtApiClient.Client
+}
+
+type vmResourceModel struct {
+	ID       types.Int64 `tfsdk:"id"`
+	Name     string      `tfsdk:"name"`
+	VCPU     types.Int64 `tfsdk:"vcpu"`
+	Memory   types.Int64 `tfsdk:"memory"`
+	Disks    []vmDisk    `tfsdk:"disks"`
+	Networks []int64     `tfsdk:"networks"`
+}
+
+type vmDisk struct {
+	ID   types.Int64 `tfsdk:"id"`
+	Size int64       `tfsdk:"size"`
+}
+
+// vmPayload is the body of the /api/v2/node endpoints, libvirtApiClient only
+// knows how to read the NodeV2 part of it.
+type vmPayload struct {
+	libvirtApiClient.NodeV2
+	VCPU     int64   `json:"vcpu"`
+	Memory   int64   `json:"memory"`
+	Networks []int64 `json:"networks"`
+}
+
+func (r *vmResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*libvirtapiResourceData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *libvirtapiResourceData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = data.client
+}
+
+// Metadata returns the resource type name.
+func (r *vmResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_vm"
+}
+
+// Schema defines the schema for the resource.
+func (r *vmResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.Int64Attribute{
+				Computed: true,
+
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Required: true,
+
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"vcpu": schema.Int64Attribute{
+				Required: true,
+			},
+			"memory": schema.Int64Attribute{
+				Description: "Memory in MiB.",
+				Required:    true,
+			},
+			"disks": schema.ListNestedAttribute{
+				Optional: true,
+
+				PlanModifiers: []planmodifier.List{
+					// Only a new, removed or resized disk replaces the VM,
+					// the computed ids are unknown whenever anything changes.
+					listplanmodifier.RequiresReplaceIf(
+						disksChanged,
+						"Adding, removing or resizing a disk replaces the VM.",
+						"Adding, removing or resizing a disk replaces the VM.",
+					),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.Int64Attribute{
+							Computed: true,
+
+							PlanModifiers: []planmodifier.Int64{
+								int64planmodifier.UseStateForUnknown(),
+							},
+						},
+						"size": schema.Int64Attribute{
+							Description: "Disk size in GiB.",
+							Required:    true,
+						},
+					},
+				},
+			},
+			"networks": schema.ListAttribute{
+				Description: "IDs of libvirtapi_network the VM is attached to.",
+				ElementType: types.Int64Type,
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// Create creates the resource and sets the initial Terraform state.
+func (r *vmResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan vmResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var vm vmPayload
+	err := apiRequest(ctx, r.client, http.MethodPost, "/api/v2/node", plan.payload(), &vm)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error creating vm",
+			"Could not create vm, unexpected error: "+err.Error(),
+		)
+		return
+	}
+	plan.ID = types.Int64Value(int64(vm.ID))
+
+	// Save the VM before attaching disks so a failure below does not leak it,
+	// disks are added to the state as soon as they exist.
+	disks := plan.Disks
+	if disks != nil {
+		plan.Disks = []vmDisk{}
+	}
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, disk := range disks {
+		created, err := createDisk(ctx, r.client, int(disk.Size))
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error creating vm disk",
+				fmt.Sprintf("Could not create disk for vm %s, unexpected error: %s", plan.Name, err.Error()),
+			)
+			break
+		}
+		disk.ID = types.Int64Value(int64(created.ID))
+
+		err = bindDisk(ctx, r.client, created.ID, plan.Name)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error attaching vm disk",
+				fmt.Sprintf("Could not attach disk %d to vm %s, unexpected error: %s", created.ID, plan.Name, err.Error()),
+			)
+			// Not attached, so it is not ours to delete later.
+			_ = deleteDisk(ctx, r.client, created.ID)
+			break
+		}
+		plan.Disks = append(plan.Disks, disk)
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (r *vmResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state vmResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vm, ok, err := getVm(ctx, r.client, state.Name)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Reading vm",
+			"Could not read vm "+state.Name+": "+err.Error(),
+		)
+		return
+	}
+	if !ok {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	state.ID = types.Int64Value(int64(vm.ID))
+	state.Name = vm.Name
+	state.VCPU = types.Int64Value(vm.VCPU)
+	state.Memory = types.Int64Value(vm.Memory)
+	if len(vm.Networks) > 0 || state.Networks != nil {
+		state.Networks = append([]int64{}, vm.Networks...)
+	}
+
+	// Only disks managed by this resource are tracked, the server also
+	// reports the boot volume.
+	disks := []vmDisk{}
+	for _, disk := range state.Disks {
+		for _, current := range vm.Disks {
+			if int64(current.ID) == disk.ID.ValueInt64() {
+				disks = append(disks, vmDisk{
+					ID:   types.Int64Value(int64(current.ID)),
+					Size: int64(current.Size),
+				})
+			}
+		}
+	}
+	if state.Disks != nil {
+		state.Disks = disks
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Update updates the resource and sets the updated Terraform state on success.
+func (r *vmResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var state vmResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+
+	var plan vmResourceModel
+	diags = req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	payload := plan.payload()
+	payload.ID = int(state.ID.ValueInt64())
+
+	var vm vmPayload
+	err := apiRequest(ctx, r.client, http.MethodPut, "/api/v2/node/"+url.PathEscape(plan.Name), payload, &vm)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Update vm",
+			"Could not update vm, unexpected error: "+err.Error(),
+		)
+		return
+	}
+
+	plan.ID = state.ID
+	plan.Disks = state.Disks
+
+	diags = resp.State.Set(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes the resource and removes the Terraform state on success.
+func (r *vmResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state vmResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, disk := range state.Disks {
+		if disk.ID.IsNull() {
+			continue
+		}
+		id := int(disk.ID.ValueInt64())
+
+		err := unbindDisk(ctx, r.client, id, state.Name)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error Deleting vm disk",
+				fmt.Sprintf("Could not detach disk %d from vm %s, unexpected error: %s", id, state.Name, err.Error()),
+			)
+			return
+		}
+		err = deleteDisk(ctx, r.client, id)
+		if err != nil && !isNotFound(err) {
+			resp.Diagnostics.AddError(
+				"Error Deleting vm disk",
+				fmt.Sprintf("Could not delete disk %d, unexpected error: %s", id, err.Error()),
+			)
+			return
+		}
+	}
+
+	err := apiRequest(ctx, r.client, http.MethodDelete, "/api/v2/node/"+url.PathEscape(state.Name), nil, nil)
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError(
+			"Error Deleting vm",
+			"Could not delete vm, unexpected error: "+err.Error(),
+		)
+		return
+	}
+}
+
+// ImportState reads the whole VM, VMs are addressed by name on the server
+// side. Every disk but the boot volume, which the server reports first, is
+// imported as managed by the resource, otherwise the next plan would replace
+// the VM to create them.
+func (r *vmResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	vm, ok, err := getVm(ctx, r.client, req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Importing vm",
+			"Could not read vm "+req.ID+": "+err.Error(),
+		)
+		return
+	}
+	if !ok {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"Error Importing vm",
+			"Could not find vm "+req.ID+".",
+		)
+		return
+	}
+
+	state := vmResourceModel{
+		ID:     types.Int64Value(int64(vm.ID)),
+		Name:   vm.Name,
+		VCPU:   types.Int64Value(vm.VCPU),
+		Memory: types.Int64Value(vm.Memory),
+	}
+	if len(vm.Networks) > 0 {
+		state.Networks = vm.Networks
+	}
+	if len(vm.Disks) > 1 {
+		for _, disk := range vm.Disks[1:] {
+			state.Disks = append(state.Disks, vmDisk{
+				ID:   types.Int64Value(int64(disk.ID)),
+				Size: int64(disk.Size),
+			})
+		}
+	}
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// disksChanged tells whether the disks differ in number or size, the ids are
+// assigned by the server and do not count.
+func disksChanged(ctx context.Context, req planmodifier.ListRequest, resp *listplanmodifier.RequiresReplaceIfFuncResponse) {
+	var state, plan []vmDisk
+	resp.Diagnostics.Append(req.StateValue.ElementsAs(ctx, &state, false)...)
+	resp.Diagnostics.Append(req.PlanValue.ElementsAs(ctx, &plan, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if len(state) != len(plan) {
+		resp.RequiresReplace = true
+		return
+	}
+	for i := range plan {
+		if plan[i].Size != state[i].Size {
+			resp.RequiresReplace = true
+			return
+		}
+	}
+}
+
+func (m vmResourceModel) payload() vmPayload {
+	return vmPayload{
+		NodeV2: libvirtApiClient.NodeV2{
+			Name: m.Name,
+		},
+		VCPU:     m.VCPU.ValueInt64(),
+		Memory:   m.Memory.ValueInt64(),
+		Networks: m.Networks,
+	}
+}
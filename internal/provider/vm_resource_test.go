@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// planTestChange plans the change from prior to proposed the way Terraform
+// does, config is what the user wrote.
+func planTestChange(t *testing.T, r resource.Resource, typeName string, prior interface{}, config interface{}, proposed interface{}) *tfprotov6.PlanResourceChangeResponse {
+	t.Helper()
+	ctx := context.Background()
+
+	var schema resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schema)
+	value := func(model interface{}) *tfprotov6.DynamicValue {
+		state := tfsdk.State{Schema: schema.Schema}
+		if d := state.Set(ctx, model); d.HasError() {
+			t.Fatalf("setting %T: %v", model, d)
+		}
+		dynamicValue, err := tfprotov6.NewDynamicValue(schema.Schema.Type().TerraformType(ctx), state.Raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &dynamicValue
+	}
+
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       value(prior),
+		Config:           value(config),
+		ProposedNewState: value(proposed),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range response.Diagnostics {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("unexpected diagnostics: %v", response.Diagnostics)
+		}
+	}
+	return response
+}
+
+// plannedTestModel reads the planned state of response into model.
+func plannedTestModel(t *testing.T, r resource.Resource, response *tfprotov6.PlanResourceChangeResponse, model interface{}) {
+	t.Helper()
+	ctx := context.Background()
+
+	var schema resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schema)
+	value, err := response.PlannedState.Unmarshal(schema.Schema.Type().TerraformType(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := (tfsdk.Plan{Raw: value, Schema: schema.Schema}).Get(ctx, model); d.HasError() {
+		t.Fatalf("reading the plan: %v", d)
+	}
+}
+
+func TestVmPlanInPlace(t *testing.T) {
+	state := vmResourceModel{
+		ID:     types.Int64Value(7),
+		Name:   "web",
+		VCPU:   types.Int64Value(2),
+		Memory: types.Int64Value(2048),
+		Disks: []vmDisk{
+			{ID: types.Int64Value(11), Size: 20},
+			{ID: types.Int64Value(12), Size: 50},
+		},
+		Networks: []int64{1},
+	}
+
+	cases := []struct {
+		name   string
+		change func(m *vmResourceModel)
+	}{
+		{"vcpu", func(m *vmResourceModel) { m.VCPU = types.Int64Value(4) }},
+		{"memory", func(m *vmResourceModel) { m.Memory = types.Int64Value(4096) }},
+		{"networks", func(m *vmResourceModel) { m.Networks = []int64{1, 2} }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			proposed := state
+			proposed.Disks = append([]vmDisk{}, state.Disks...)
+			c.change(&proposed)
+
+			// Computed attributes are null in the config.
+			config := proposed
+			config.ID = types.Int64Null()
+			config.Disks = []vmDisk{
+				{ID: types.Int64Null(), Size: 20},
+				{ID: types.Int64Null(), Size: 50},
+			}
+
+			response := planTestChange(t, NewVmResource(), "libvirtapi_vm", state, config, proposed)
+			if len(response.RequiresReplace) != 0 {
+				t.Errorf("planned a replacement of %v, want an update", response.RequiresReplace)
+			}
+
+			var planned vmResourceModel
+			plannedTestModel(t, NewVmResource(), response, &planned)
+			if len(planned.Disks) != 2 || planned.Disks[0].ID.ValueInt64() != 11 || planned.Disks[1].ID.ValueInt64() != 12 {
+				t.Errorf("planned disks %+v, want the ids of the state", planned.Disks)
+			}
+		})
+	}
+}
+
+func TestVmPlanDisksReplace(t *testing.T) {
+	state := vmResourceModel{
+		ID:     types.Int64Value(7),
+		Name:   "web",
+		VCPU:   types.Int64Value(2),
+		Memory: types.Int64Value(2048),
+		Disks:  []vmDisk{{ID: types.Int64Value(11), Size: 20}},
+	}
+
+	cases := []struct {
+		name  string
+		sizes []int64
+	}{
+		{"resize", []int64{40}},
+		{"add", []int64{20, 10}},
+		{"remove", []int64{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			proposed := state
+			proposed.Disks = []vmDisk{}
+			config := state
+			config.ID = types.Int64Null()
+			config.Disks = []vmDisk{}
+			for i, size := range c.sizes {
+				id := types.Int64Unknown()
+				if i < len(state.Disks) {
+					id = state.Disks[i].ID
+				}
+				proposed.Disks = append(proposed.Disks, vmDisk{ID: id, Size: size})
+				config.Disks = append(config.Disks, vmDisk{ID: types.Int64Null(), Size: size})
+			}
+
+			response := planTestChange(t, NewVmResource(), "libvirtapi_vm", state, config, proposed)
+			if len(response.RequiresReplace) != 1 || response.RequiresReplace[0].String() != `AttributeName("disks")` {
+				t.Errorf("got RequiresReplace %v, want disks", response.RequiresReplace)
+			}
+		})
+	}
+}